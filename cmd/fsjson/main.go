@@ -1,17 +1,16 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"fsjson/internal/app"
 	"fsjson/internal/config"
-	"fsjson/internal/domain/model"
 	"fsjson/internal/domain/service"
 	"fsjson/internal/infrastructure"
 )
@@ -31,73 +30,360 @@ var (
 	mergeFlatFlag      = flag.Bool("merge-flat", false, "Сохранять объединённый результат в плоском виде ([]FileInfo)")
 	mergeChildrenFlag  = flag.Bool("merge-children", false, "Объединять только дочерние элементы корней")
 	webFlag            = flag.Bool("web", false, "Запустить веб-интерфейс для просмотра JSON")
-	fileFlag           = flag.String("file", "", "JSON-файл для просмотра в веб-интерфейсе")
+	fileFlag           = new(string) // --file, последний из указанных
 	searchFlag         = flag.Bool("search", false, "Поиск по JSON-файлу (--file=...)")
-	searchQuery        = flag.String("query", "", "Запрос поиска")
+	tuiFlag            = flag.Bool("tui", false, "Просмотр JSON-файла в терминале, как в ncdu (--file=...)")
+	exportNcduFlag     = flag.String("export-ncdu", "", "Записать снимок (--file=...) в формате дампа ncdu для ncdu -f; \"-\" — в stdout")
+	topFlag            = flag.Bool("top", false, "Самые большие файлы и директории, самые населённые директории и самые глубокие пути в JSON-файле")
+	statsFlag          = flag.Bool("stats", false, "Статистика по JSON-файлу: количество и размеры файлов по группам (--group-by=...)")
 	searchPath         = flag.String("path", "", "Путь для поиска")
 	searchTypeFile     = flag.String("type", "", "Поиск по типу")
 	searchLimit        = flag.Int("limit", 100, "Поиск по типу")
 	searchOffset       = flag.Int("offset", 0, "Поиск по типу")
-	searchCreated      = flag.String("created", "", "Поиск по дате создания")
-	searchModified     = flag.String("modified", "", "Поиск по дате изменения")
+	outputFormatFlag   = flag.String("output-format", "text", "Формат результатов поиска и статистики: text, json, ndjson, csv или paths0 (через NUL, для xargs -0)")
+	fieldsFlag         = flag.String("fields", "", "Поля результатов поиска через запятую: path, name, size, type, modified, created")
 	findDuplicatesFlag = flag.Bool("find-duplicates", false, "Найти дубликаты по MD5")
+	envelopeFlag       = flag.Bool("envelope", false, "Сохранять результат в конверте с метаданными сканирования")
+	byteCompareFlag    = flag.Bool("byte-compare", false, "Для --find-duplicates --dir: побайтно сравнить файлы с одинаковым MD5")
+	dupActionFlag      = flag.String("dup-action", "", "Действие над дубликатами: hardlink, reflink или delete")
+	keepFlag           = flag.String("keep", "shortest", "Какой файл оставить: oldest, newest, shortest, priority, inside")
+	keepPathsFlag      = flag.String("keep-paths", "", "Пути через запятую для --keep=priority и --keep=inside")
+	applyFlag          = flag.Bool("apply", false, "Выполнить действие над дубликатами (по умолчанию — пробный запуск)")
+	dupScriptFlag      = flag.String("dup-script", "", "Записать shell-скрипт с действиями вместо выполнения")
+	minSizeFlag        = flag.String("min-size", "0", "Минимальный размер файла для поиска дубликатов (1048576, 100MB, 1.5GB)")
+	extFlag            = flag.String("ext", "", "Расширения через запятую (без точки)")
+	sortFlag           = flag.String("sort", "", "Сортировка: дубликаты — wasted, count, size; поиск — name, size, modified, created, path, depth, score; статистика — sum, count, avg, min, max, key")
+	dupTopDirsFlag     = flag.Int("dup-top-dirs", 0, "Показать N директорий с наибольшим объёмом дублированных данных")
+	crossOnlyFlag      = flag.Bool("cross-only", false, "При поиске дубликатов по нескольким снимкам — только копии в разных снимках")
+	contentIndexFlag   = flag.Bool("content-index", false, "Строить индекс содержимого текстовых файлов и кода рядом с результатом (<имя>.content.json)")
+	contentMaxSizeFlag = flag.String("content-max-size", "1MB", "Максимальный размер файла для индекса содержимого")
+	imageHashFlag      = flag.String("image-hash", "", "Перцептивный хеш изображений при сканировании: ahash, dhash или phash")
+	findSimilarFlag    = flag.Bool("find-similar-images", false, "Поиск похожих изображений в JSON-файле (по ImageHash)")
+	distanceFlag       = flag.Int("distance", 10, "Максимальное расстояние Хэмминга (из 64 бит) для похожих изображений")
+	findDupDirsFlag    = flag.Bool("find-duplicate-dirs", false, "Поиск одинаковых директорий в JSON-файле")
+	similarityFlag     = flag.Float64("similarity", 100, "Порог похожести директорий в % (меньше 100 — искать и частичные совпадения)")
+	merkleFlag         = flag.Bool("merkle", false, "Считать хеши директорий по содержимому (Merkle)")
+	validateFlag       = flag.String("validate", "", "Проверить структуру и инварианты JSON-файла")
+	printSchemaFlag    = flag.Bool("print-schema", false, "Вывести JSON Schema форматов снимка")
+	hashFlag           = flag.String("hash", "", "Дополнительные алгоритмы хеширования через запятую (sha1,sha256,sha512); для манифеста — алгоритм")
+	exportManifestFlag = flag.String("export-manifest", "", "Записать файл контрольных сумм из снимка (--file=...)")
+	manifestFormatFlag = flag.String("manifest-format", "gnu", "Формат контрольных сумм: gnu (md5sum/sha256sum) или bsd (--tag)")
+	verifyManifestFlag = flag.String("verify-manifest", "", "Проверить файлы на диске по манифесту или JSON-снимку")
+	verifyBaselineFlag = flag.String("verify-baseline", "", "Проверить целостность: пересканировать корень и сравнить с эталонным снимком")
+	verifyOutputFlag   = flag.String("verify-output", "", "Файл для JSON-отчёта о нарушениях целостности")
+	signKeyFlag        = flag.String("sign-key", "", "Приватный ключ ed25519 (PEM) для подписи сохраняемых файлов")
+	verifySigFlag      = flag.String("verify-signature", "", "Публичный ключ ed25519 (PEM): требовать подпись читаемых файлов")
+	genSignKeyFlag     = flag.String("gen-sign-key", "", "Создать пару ключей ed25519: <имя>.key и <имя>.pub")
 )
 
+// fileFlags — все значения --file: для --find-duplicates его можно повторить.
+// Запятая — допустимый символ в имени файла, поэтому список не разбирается из одной строки.
+var fileFlags []string
+
+func init() {
+	flag.Func("file", "JSON-файл снимка; для --find-duplicates можно повторить: --file=a.json --file=b.json", func(s string) error {
+		fileFlags = append(fileFlags, s)
+		*fileFlag = s
+		return nil
+	})
+}
+
 func main() {
+	config.RegisterSearchFlags() // --size.gt=1GB, --modified=today, --depth.lte=2, --perm=755 …
+	config.RegisterStatsFlags()
+	config.RegisterTopFlags()
 	config.ParseFlagsSafe()
+	if len(fileFlags) > 1 && !*findDuplicatesFlag {
+		log.Fatal("Несколько --file поддерживает только --find-duplicates")
+	}
+	if err := checkDistinctFiles(fileFlags); err != nil {
+		// иначе каждый файл снимка оказался бы «дубликатом между снимками» самого себя
+		log.Fatal(err)
+	}
+
+	if *printSchemaFlag {
+		app.PrintSchema()
+		return
+	}
+
+	if *genSignKeyFlag != "" {
+		if err := infrastructure.GenerateSigningKey(*genSignKeyFlag); err != nil {
+			log.Fatalf("Ошибка создания ключей: %v", err)
+		}
+		fmt.Printf("🔑 Ключи созданы: %s.key (приватный), %s.pub (публичный)\n", *genSignKeyFlag, *genSignKeyFlag)
+		return
+	}
+	if *signKeyFlag != "" {
+		if err := infrastructure.InitSigner(*signKeyFlag); err != nil {
+			log.Fatalf("Ошибка загрузки ключа подписи: %v", err)
+		}
+	}
+	if *verifySigFlag != "" {
+		if err := infrastructure.InitSignatureVerifier(*verifySigFlag); err != nil {
+			log.Fatalf("Ошибка загрузки публичного ключа: %v", err)
+		}
+	}
+
+	imageAlgo, err := service.ParseImageHashAlgorithm(*imageHashFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	hashAlgos, err := service.ParseHashAlgorithms(*hashFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	contentMaxSize, err := service.ParseSize(*contentMaxSizeFlag)
+	if err != nil {
+		log.Fatalf("--content-max-size: %v", err)
+	}
+
+	if *exportManifestFlag != "" {
+		if *fileFlag == "" {
+			log.Fatal("Укажите JSON-файл через --file")
+		}
+		if *manifestFormatFlag != service.ManifestGNU && *manifestFormatFlag != service.ManifestBSD {
+			log.Fatalf("Неизвестный формат контрольных сумм: %s", *manifestFormatFlag)
+		}
+		ok := app.ExportManifestMode(app.ManifestConfig{
+			Snapshot: *fileFlag,
+			Output:   *exportManifestFlag,
+			Algo:     firstOr(hashAlgos, "md5"),
+			Format:   *manifestFormatFlag,
+		})
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *exportNcduFlag != "" {
+		if *fileFlag == "" {
+			log.Fatal("Укажите JSON-файл через --file")
+		}
+		if err := app.ExportNcduMode(app.NcduConfig{Snapshot: *fileFlag, Output: *exportNcduFlag}); err != nil {
+			log.Fatalf("Ошибка экспорта в ncdu: %v", err)
+		}
+		return
+	}
+
+	if *tuiFlag {
+		if *fileFlag == "" {
+			log.Fatal("Укажите JSON-файл через --file")
+		}
+		if err := app.TUIMode(app.TUIConfig{File: *fileFlag}); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *verifyManifestFlag != "" {
+		code := app.VerifyManifestMode(app.VerifyManifestConfig{
+			Source:  *verifyManifestFlag,
+			Algo:    firstOr(hashAlgos, "md5"),
+			Workers: *workersFlag,
+			IOLimit: *ioLimitFlag,
+		})
+		if code != app.VerifyPassed {
+			os.Exit(code)
+		}
+		return
+	}
+
+	if *verifyBaselineFlag != "" {
+		cfg := app.IntegrityConfig{
+			Baseline: *verifyBaselineFlag,
+			Output:   *verifyOutputFlag,
+			Workers:  *workersFlag,
+			IOLimit:  *ioLimitFlag,
+		}
+		if config.FlagPassed("dir") {
+			cfg.RootDir = *dirFlag
+		}
+		if config.FlagPassed("exclude") {
+			cfg.Exclude = splitCSV(*excludeFlag)
+		}
+		os.Exit(app.IntegrityMode(cfg))
+	}
+
+	if *validateFlag != "" {
+		if !app.ValidateMode(*validateFlag) {
+			os.Exit(1)
+		}
+		return
+	}
 
 	if *searchFlag {
 		if *fileFlag == "" {
 			log.Fatal("Укажите JSON-файл через --file")
 		}
-		data, err := os.ReadFile(*fileFlag)
+
+		params, err := config.ParseSearchParams(config.FlagValue, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+		params.ContentIndex = infrastructure.ContentIndexLoader(*fileFlag)
+		params.AllowDiskLike = true
+
+		format, err := app.ParseOutputFormat(*outputFormatFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fields, err := app.ParseSearchFields(*fieldsFlag, format)
 		if err != nil {
 			log.Fatal(err)
 		}
-		var root model.FileInfo
-		if err := json.Unmarshal(data, &root); err != nil {
+		results, err := service.SearchIn(infrastructure.FileSource(*fileFlag), params)
+		if err != nil {
 			log.Fatal(err)
 		}
+		if err := app.WriteSearchResults(os.Stdout, os.Stderr, results, format, fields); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-		// разбор параметров из env/cli (упрощённо)
-		params := service.SearchParams{
-			Query:     *searchQuery,
-			Path:      *searchPath,
-			Types:     strings.Split(*searchTypeFile, ","),
-			Recursive: true,
-			Limit:     *searchLimit,
-			Offset:    *searchOffset,
-			//SizeCmp:   parseSizeFlags(),
-			Created:  config.ParseTimeFilters(*searchCreated),
-			Modified: config.ParseTimeFilters(*searchModified),
+	if *statsFlag {
+		if *fileFlag == "" {
+			log.Fatal("Укажите JSON-файл через --file")
 		}
+		params, err := config.ParseStatsParams(config.FlagValue, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+		params.Search.ContentIndex = infrastructure.ContentIndexLoader(*fileFlag)
+		params.Search.AllowDiskLike = true
+		format, err := app.ParseOutputFormat(*outputFormatFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		res, err := service.Aggregate(infrastructure.FileSource(*fileFlag), params)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := app.WriteStats(os.Stdout, res, format); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-		results := service.SearchFiles(&root, params)
-		for _, r := range results.Results {
-			fmt.Printf("%s (%s, %d bytes)\n", r.FullPathOrig, r.FileType, r.SizeBytes)
+	if *topFlag {
+		if *fileFlag == "" {
+			log.Fatal("Укажите JSON-файл через --file")
+		}
+		params, err := config.ParseTopParams(config.FlagValue, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+		params.Search.ContentIndex = infrastructure.ContentIndexLoader(*fileFlag)
+		params.Search.AllowDiskLike = true
+		format, err := app.ParseOutputFormat(*outputFormatFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		res, err := service.Top(infrastructure.FileSource(*fileFlag), params)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := app.WriteTop(os.Stdout, res, format); err != nil {
+			log.Fatal(err)
 		}
-		fmt.Printf("🔍 Найдено %d элементов\n", results.Total)
 		return
 	}
 
 	if *findDuplicatesFlag {
-		data, err := os.ReadFile(*fileFlag)
+		dupSort, err := service.ParseDuplicateSort(*sortFlag)
 		if err != nil {
-			log.Fatalf("Ошибка чтения %s: %v", *fileFlag, err)
+			log.Fatal(err)
 		}
-		var root model.FileInfo
-		if err := json.Unmarshal(data, &root); err != nil {
-			log.Fatalf("Ошибка разбора JSON: %v", err)
+		minSize, err := service.ParseSize(*minSizeFlag)
+		if err != nil {
+			log.Fatalf("--min-size: %v", err)
+		}
+		filter := service.DuplicateFilter{
+			MinSize:    minSize,
+			Types:      splitCSV(*searchTypeFile),
+			Exts:       splitCSV(*extFlag),
+			PathPrefix: *searchPath,
+			Sort:       dupSort,
+			TopDirs:    *dupTopDirsFlag,
+			CrossOnly:  *crossOnlyFlag,
+		}
+		if config.FlagPassed("limit") {
+			filter.Limit = *searchLimit
+		}
+		if config.FlagPassed("offset") {
+			filter.Offset = *searchOffset
 		}
 
-		res := service.FindDuplicates(&root)
-		fmt.Printf("🔍 Найдено групп дубликатов: %d, файлов-дубликатов: %d\n\n", res.Total, res.Files)
-		for _, g := range res.Groups {
-			fmt.Printf("🧩 MD5: %s (%d файлов, общий размер: %d байт)\n", g.Md5, g.Count, g.Size)
-			for _, f := range g.Files {
-				fmt.Printf("   %s\n", f)
+		var res service.DuplicatesResponse
+		if len(fileFlags) > 1 && *dupActionFlag != "" {
+			// пути из разных снимков могут быть с других машин, других точек
+			// монтирования или повторять друг друга — действовать по ним на этом диске нельзя
+			log.Fatal("--dup-action работает только с одним снимком (--file) или с --dir")
+		}
+		switch snapshots := fileFlags; {
+		case len(snapshots) > 1:
+			srcs := make([]service.NodeSource, len(snapshots))
+			for i, s := range snapshots {
+				srcs[i] = infrastructure.FileSource(s)
+			}
+			res, err = service.FindDuplicatesAcross(srcs, snapshots, filter)
+			if err != nil {
+				log.Fatalf("Ошибка разбора JSON: %v", err)
+			}
+		case *fileFlag != "":
+			res, err = service.FindDuplicatesIn(infrastructure.FileSource(*fileFlag), filter)
+			if err != nil {
+				log.Fatalf("Ошибка разбора JSON: %v", err)
+			}
+		case config.FlagPassed("dir"):
+			res = app.DuplicateScanMode(app.DuplicateScanConfig{
+				RootDir:     *dirFlag,
+				Exclude:     splitCSV(*excludeFlag),
+				Workers:     *workersFlag,
+				IOLimit:     *ioLimitFlag,
+				ByteCompare: *byteCompareFlag,
+				Filter:      filter,
+			})
+		default:
+			log.Fatal("Укажите JSON-файл через --file или директорию через --dir")
+		}
+		if *dupActionFlag != "" {
+			ok := app.ResolveDuplicates(res, app.DupActionConfig{
+				Action:    *dupActionFlag,
+				Keep:      *keepFlag,
+				KeepPaths: splitPaths(*keepPathsFlag),
+				Apply:     *applyFlag,
+				Script:    *dupScriptFlag,
+				IOLimit:   *ioLimitFlag,
+			})
+			if !ok {
+				os.Exit(1)
 			}
-			fmt.Println()
+			return
+		}
+		app.PrintDuplicates(res, filter)
+		return
+	}
+
+	if *findSimilarFlag {
+		if *fileFlag == "" {
+			log.Fatal("Укажите JSON-файл через --file")
+		}
+		res, err := service.FindSimilarImagesIn(infrastructure.FileSource(*fileFlag), *distanceFlag)
+		if err != nil {
+			log.Fatalf("Ошибка разбора JSON: %v", err)
+		}
+		app.PrintSimilarImages(res)
+		return
+	}
+
+	if *findDupDirsFlag {
+		if *fileFlag == "" {
+			log.Fatal("Укажите JSON-файл через --file")
+		}
+		if err := app.DuplicateDirsMode(app.DuplicateDirsConfig{File: *fileFlag, Similarity: *similarityFlag}); err != nil {
+			log.Fatalf("Ошибка разбора JSON: %v", err)
 		}
 		return
 	}
@@ -120,6 +406,8 @@ func main() {
 			Dedupe:        *dedupeFlag,
 			MergeFlat:     *mergeFlatFlag,
 			MergeChildren: *mergeChildrenFlag,
+			Envelope:      *envelopeFlag,
+			Merkle:        *merkleFlag,
 		}
 		app.MergeMode(cfg)
 		return
@@ -135,6 +423,15 @@ func main() {
 		SkipMD5: *skipMd5Flag,
 		IOLimit: *ioLimitFlag,
 		Resume:  *resumeFlag,
+
+		Envelope: *envelopeFlag,
+		Merkle:   *merkleFlag,
+		Hashes:   hashAlgos,
+
+		ImageHash: imageAlgo,
+
+		ContentIndex:   *contentIndexFlag,
+		ContentMaxSize: contentMaxSize,
 	}
 
 	if *streamFlag {
@@ -158,3 +455,40 @@ func splitCSV(s string) []string {
 	}
 	return out
 }
+
+// splitPaths — как splitCSV, но без приведения к нижнему регистру
+func splitPaths(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// checkDistinctFiles — пути указывают на разные файлы: одинаковое имя, другое написание,
+// симлинк и жёсткая ссылка на один и тот же снимок отвергаются
+func checkDistinctFiles(paths []string) error {
+	infos := make([]os.FileInfo, len(paths))
+	for i, p := range paths {
+		st, err := os.Stat(p)
+		if err != nil {
+			continue // об ошибке чтения сообщит сам режим
+		}
+		for j := range i {
+			if infos[j] != nil && os.SameFile(infos[j], st) {
+				return fmt.Errorf("--file указан дважды: %s и %s — один и тот же файл", paths[j], p)
+			}
+		}
+		infos[i] = st
+	}
+	return nil
+}
+
+func firstOr(list []string, def string) string {
+	if len(list) == 0 {
+		return def
+	}
+	return list[0]
+}
@@ -1,5 +1,7 @@
 package app
 
+import "fsjson/internal/domain/service"
+
 // ScanConfig — параметры сканирования
 type ScanConfig struct {
 	RootDir string
@@ -10,6 +12,15 @@ type ScanConfig struct {
 	SkipMD5 bool
 	IOLimit int
 	Resume  bool // TODO: пока не реализовано в stream-режиме
+
+	Envelope bool     // сохранять результат в конверте с метаданными
+	Hashes   []string // дополнительные алгоритмы хеширования (sha1, sha256, ...)
+	Merkle   bool     // хеши директорий по содержимому (Merkle)
+
+	ImageHash string // алгоритм перцептивного хеша изображений (ahash, dhash, phash)
+
+	ContentIndex   bool  // индекс содержимого текстовых файлов рядом с результатом
+	ContentMaxSize int64 // файлы больше не индексируются
 }
 
 // MergeConfig — параметры объединения
@@ -20,4 +31,69 @@ type MergeConfig struct {
 	Dedupe        bool
 	MergeFlat     bool
 	MergeChildren bool
+	Envelope      bool
+	Merkle        bool // пересчитать хеши директорий по содержимому
+}
+
+// DuplicateScanConfig — параметры поиска дубликатов прямо на диске
+type DuplicateScanConfig struct {
+	RootDir     string
+	Exclude     []string
+	Workers     int
+	IOLimit     int
+	ByteCompare bool // финальная побайтная проверка групп с одинаковым MD5
+	Filter      service.DuplicateFilter
+}
+
+// DupActionConfig — что делать с найденными дубликатами
+type DupActionConfig struct {
+	Action    string   // hardlink | reflink | delete
+	Keep      string   // политика выбора оставляемого файла
+	KeepPaths []string // пути для политик priority и inside
+	Apply     bool     // без него — только пробный запуск
+	Script    string   // записать shell-скрипт вместо выполнения
+	IOLimit   int
+}
+
+// DuplicateDirsConfig — параметры поиска одинаковых директорий
+type DuplicateDirsConfig struct {
+	File       string
+	Similarity float64 // порог похожести, %; 100 — только полные копии
+}
+
+// ManifestConfig — параметры экспорта файла контрольных сумм
+type ManifestConfig struct {
+	Snapshot string
+	Output   string
+	Algo     string
+	Format   string // gnu | bsd
+}
+
+// VerifyManifestConfig — параметры проверки файлов по манифесту или снимку
+type VerifyManifestConfig struct {
+	Source  string // файл контрольных сумм или JSON-снимок
+	Algo    string // алгоритм для JSON-снимка (по умолчанию md5)
+	Workers int
+	IOLimit int
+}
+
+// IntegrityConfig — параметры проверки целостности по эталонному снимку
+type IntegrityConfig struct {
+	Baseline string   // доверенный снимок
+	RootDir  string   // что пересканировать (по умолчанию — корень эталона)
+	Exclude  []string // по умолчанию — исключения из конверта эталона
+	Output   string   // файл для JSON-отчёта о нарушениях
+	Workers  int
+	IOLimit  int
+}
+
+// NcduConfig — параметры экспорта снимка в формат дампа ncdu
+type NcduConfig struct {
+	Snapshot string
+	Output   string // "-" — stdout
+}
+
+// TUIConfig — параметры терминального просмотра снимка
+type TUIConfig struct {
+	File string
 }
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"fsjson/internal/domain/service"
+	"fsjson/internal/infrastructure"
+)
+
+// ResolveDuplicates применяет действие к найденным дубликатам: в каждой группе
+// по политике выбирается оставляемый файл, остальные заменяются ссылками или удаляются.
+// Без cfg.Apply только печатает план; с cfg.Script пишет shell-скрипт вместо выполнения.
+// Перед каждым действием оба файла перечитываются и сверяются с хешем группы.
+// Возвращает false, если хотя бы одно действие не удалось.
+func ResolveDuplicates(res service.DuplicatesResponse, cfg DupActionConfig) bool {
+	op, err := service.ParseDupAction(cfg.Action)
+	if err != nil {
+		fmt.Println("❌", err)
+		return false
+	}
+	policy, err := service.ParseKeepPolicy(cfg.Keep, cfg.KeepPaths)
+	if err != nil {
+		fmt.Println("❌", err)
+		return false
+	}
+	infrastructure.InitIOLimiter(cfg.IOLimit)
+
+	var actions []service.DupAction
+	var skipped, single int
+	for _, g := range res.Groups {
+		// один и тот же путь может прийти дважды (снимок прочитан два раза)
+		var files []service.DupFile
+		seen := make(map[string]bool, len(g.Files))
+		for _, p := range g.Files {
+			if seen[absPath(p)] {
+				continue
+			}
+			seen[absPath(p)] = true
+			st, err := os.Lstat(p)
+			if err != nil || !st.Mode().IsRegular() {
+				fmt.Printf("⚠️ %s: пропущен (%v)\n", p, describeStatErr(err))
+				continue
+			}
+			files = append(files, service.DupFile{Path: p, Size: st.Size(), ModTime: st.ModTime()})
+		}
+		if len(files) < 2 {
+			single++
+			continue
+		}
+		keep, ok := service.ChooseKeeper(files, policy)
+		if !ok {
+			skipped++
+			continue
+		}
+		for i, f := range files {
+			if i == keep {
+				continue
+			}
+			size := f.Size
+			shared, same, err := infrastructure.SameFileLinks(files[keep].Path, f.Path)
+			switch {
+			case err != nil:
+				fmt.Printf("⚠️ %s: пропущен (%v)\n", f.Path, err)
+				continue
+			case same:
+				fmt.Printf("⚠️ %s: та же запись, что и %s, — пропущен\n", f.Path, files[keep].Path)
+				continue
+			case shared && op != service.DupDelete:
+				continue // уже одна и та же копия на диске
+			case shared:
+				size = 0 // удаляется лишь ещё одна жёсткая ссылка на тот же файл
+			}
+			actions = append(actions, service.DupAction{
+				Op: op, Keep: files[keep].Path, Target: f.Path, Size: size, Hash: g.Md5,
+			})
+		}
+	}
+
+	var planned int64
+	for _, a := range actions {
+		planned += a.Size
+	}
+	if single > 0 {
+		fmt.Printf("ℹ️  Групп, где на диске осталась одна копия: %d — не трогаем\n", single)
+	}
+	if skipped > 0 {
+		fmt.Printf("ℹ️  Групп без подходящего файла для политики %s: %d — не трогаем\n", policy.Mode, skipped)
+	}
+
+	if cfg.Script != "" {
+		if err := os.WriteFile(cfg.Script, []byte(service.DuplicateScript(actions, policy)), 0755); err != nil {
+			fmt.Println("❌ Ошибка записи скрипта:", err)
+			return false
+		}
+		fmt.Printf("📝 Скрипт записан: %s (%d действий, освободится до %s)\n",
+			cfg.Script, len(actions), service.HumanSize(planned))
+		return true
+	}
+
+	if !cfg.Apply {
+		fmt.Printf("🧪 Пробный запуск: %s, политика %s — ничего не изменено (--apply для выполнения)\n\n", op, policy.Mode)
+		keep := ""
+		for _, a := range actions {
+			if a.Keep != keep {
+				keep = a.Keep
+				fmt.Printf("✅ %s\n", keep)
+			}
+			fmt.Printf("   %-8s %s\n", a.Op, a.Target)
+		}
+		fmt.Printf("\n📊 Действий: %d | освободится до %s\n", len(actions), service.HumanSize(planned))
+		return true
+	}
+
+	var done, failed int
+	var freed int64
+	for _, a := range actions {
+		if err := applyDupAction(a); err != nil {
+			fmt.Printf("❌ %s %s: %v\n", a.Op, a.Target, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✔ %-8s %s → %s\n", a.Op, a.Target, a.Keep)
+		done++
+		freed += a.Size
+	}
+	fmt.Printf("📊 Выполнено: %d | ошибок: %d | освобождено до %s\n", done, failed, service.HumanSize(freed))
+	return failed == 0
+}
+
+// applyDupAction перепроверяет содержимое и выполняет одно действие.
+// Непосредственно перед ним ещё раз проверяется, что keep и target — разные записи:
+// иначе удаление «дубликата» удалило бы единственную копию.
+func applyDupAction(a service.DupAction) error {
+	shared, same, err := infrastructure.SameFileLinks(a.Keep, a.Target)
+	if err != nil {
+		return err
+	}
+	if same {
+		return fmt.Errorf("%s и %s — одна и та же запись, пропущен", a.Target, a.Keep)
+	}
+	if shared && a.Op != service.DupDelete {
+		return nil
+	}
+	for _, p := range []string{a.Keep, a.Target} {
+		var sums map[string]string
+		var err error
+		infrastructure.WithIOLimit(func() {
+			sums, err = service.FileHashes(p, []string{"md5"})
+		})
+		if err != nil {
+			return err
+		}
+		if sums["md5"] != a.Hash {
+			return fmt.Errorf("%s изменился после поиска дубликатов — пропущен", p)
+		}
+	}
+
+	switch a.Op {
+	case service.DupHardlink:
+		return infrastructure.ReplaceWithHardlink(a.Keep, a.Target)
+	case service.DupReflink:
+		return infrastructure.ReplaceWithReflink(a.Keep, a.Target)
+	case service.DupDelete:
+		return os.Remove(a.Target)
+	}
+	return fmt.Errorf("неизвестное действие %q", a.Op)
+}
+
+func describeStatErr(err error) string {
+	if err == nil {
+		return "не обычный файл"
+	}
+	return strings.TrimPrefix(err.Error(), "lstat ")
+}
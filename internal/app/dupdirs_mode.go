@@ -0,0 +1,42 @@
+package app
+
+import (
+	"fmt"
+
+	"fsjson/internal/domain/service"
+	"fsjson/internal/infrastructure"
+)
+
+// DuplicateDirsMode ищет в снимке одинаковые (и при Similarity < 100 — похожие) директории
+func DuplicateDirsMode(cfg DuplicateDirsConfig) error {
+	root, err := infrastructure.ReadTreeJSON(cfg.File)
+	if err != nil {
+		return err
+	}
+	res := service.FindDuplicateDirs(&root, cfg.Similarity)
+	if res.HashAlgo == "" {
+		fmt.Println("⚠️ В снимке нет хешей файлов — сравнивать директории не по чему (сканирование с --no-md5?)")
+		return nil
+	}
+
+	fmt.Printf("📂 Найдено групп одинаковых директорий: %d (по %s), можно освободить: %s\n\n",
+		len(res.Groups), res.HashAlgo, service.HumanSize(res.Reclaimable))
+	for _, g := range res.Groups {
+		fmt.Printf("🧩 %d копии по %s (%d файлов), освободится %s\n",
+			g.Count, service.HumanSize(g.Size), g.Files, service.HumanSize(g.Reclaimable))
+		for _, d := range g.Dirs {
+			fmt.Printf("   %s\n", d)
+		}
+		fmt.Println()
+	}
+
+	if len(res.Similar) > 0 {
+		fmt.Printf("🔀 Похожие директории (≥ %.0f%%): %d\n\n", cfg.Similarity, len(res.Similar))
+		for _, p := range res.Similar {
+			fmt.Printf("   %5.1f%%  %s (%s)\n          %s (%s)\n          общих файлов на %s\n",
+				p.Similarity, p.A, service.HumanSize(p.SizeA), p.B, service.HumanSize(p.SizeB),
+				service.HumanSize(p.Shared))
+		}
+	}
+	return nil
+}
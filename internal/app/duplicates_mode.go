@@ -0,0 +1,47 @@
+package app
+
+import (
+	"fmt"
+
+	"fsjson/internal/domain/service"
+)
+
+// PrintDuplicates печатает отчёт о дубликатах: итоги, страницу групп и сводку по директориям
+func PrintDuplicates(res service.DuplicatesResponse, filter service.DuplicateFilter) {
+	fmt.Printf("🔍 Найдено групп дубликатов: %d, файлов-дубликатов: %d, лишнее место: %s\n",
+		res.Total, res.Files, service.HumanSize(res.Wasted))
+	if len(res.Groups) < res.Total {
+		from := min(max(filter.Offset, 0), res.Total)
+		fmt.Printf("📄 Показаны группы %d–%d (сортировка: %s)\n", from+1, from+len(res.Groups), filter.Sort)
+	}
+	fmt.Println()
+
+	for _, g := range res.Groups {
+		fmt.Printf("🧩 MD5: %s (%d файлов по %s, лишнее: %s)\n",
+			g.Md5, g.Count, service.HumanSize(g.FileSize), service.HumanSize(g.Wasted))
+		for i, f := range g.Files {
+			if g.Locations != nil {
+				fmt.Printf("   [%s] %s\n", g.Locations[i].Snapshot, f)
+				continue
+			}
+			fmt.Printf("   %s\n", f)
+		}
+		fmt.Println()
+	}
+
+	if len(res.Snapshots) > 0 {
+		fmt.Println("💽 Дублированные данные по снимкам:")
+		for _, s := range res.Snapshots {
+			fmt.Printf("   %10s  %5d файлов  групп только здесь: %-4d %s\n",
+				service.HumanSize(s.Bytes), s.Files, s.Only, s.Snapshot)
+		}
+		fmt.Println()
+	}
+
+	if len(res.Dirs) > 0 {
+		fmt.Println("📂 Больше всего дублированных данных:")
+		for _, d := range res.Dirs {
+			fmt.Printf("   %10s  %5d файлов  %s\n", service.HumanSize(d.Bytes), d.Files, d.Dir)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+	"fsjson/internal/infrastructure"
+)
+
+// DuplicateScanMode ищет дубликаты прямо на диске, читая как можно меньше:
+// размер → хеш первых/последних 4 КБ → полный MD5 → (опционально) побайтное сравнение.
+// Каждый следующий этап проверяет только файлы, оставшиеся в группах после предыдущего.
+func DuplicateScanMode(cfg DuplicateScanConfig) service.DuplicatesResponse {
+	start := time.Now()
+	infrastructure.InitIOLimiter(cfg.IOLimit)
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+
+	var files []service.DupCandidate
+	var walkErrs int64
+	filepath.WalkDir(cfg.RootDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			walkErrs++
+			return nil
+		}
+		if service.ShouldExclude(path, cfg.Exclude) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			walkErrs++
+			return nil
+		}
+		node := model.FileInfo{
+			FullPath:  path,
+			SizeBytes: info.Size(),
+			Ext:       strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."),
+			FileType:  service.DetectFileType(path),
+		}
+		if !cfg.Filter.Match(&node) {
+			return nil
+		}
+		files = append(files, service.DupCandidate{Path: path, Size: info.Size()})
+		return nil
+	})
+
+	groups := service.GroupBySize(files)
+	fmt.Printf("📏 Файлов: %d | одного размера: %s\n", len(files), describeGroups(groups))
+
+	var readErrs int64
+	hashAll := func(hash func(service.DupCandidate) (string, error)) map[string]string {
+		var files []service.DupCandidate
+		for _, g := range groups {
+			files = append(files, g...)
+		}
+		keys := make([]string, len(files))
+		parallelEach(len(files), cfg.Workers, func(i int) {
+			var err error
+			if keys[i], err = hash(files[i]); err != nil {
+				atomic.AddInt64(&readErrs, 1)
+			}
+		})
+		out := make(map[string]string, len(files))
+		for i, f := range files {
+			out[f.Path] = keys[i]
+		}
+		return out
+	}
+
+	partial := hashAll(func(f service.DupCandidate) (h string, err error) {
+		infrastructure.WithIOLimit(func() { h, err = service.PartialHash(f.Path) })
+		return h, err
+	})
+	groups = service.SplitGroups(groups, partial)
+	fmt.Printf("🧩 Совпали начало и конец: %s\n", describeGroups(groups))
+
+	// небольшие файлы уже прочитаны целиком — их частичный хеш и есть MD5
+	full := hashAll(func(f service.DupCandidate) (string, error) {
+		if service.PartialHashIsFull(f.Size) {
+			return partial[f.Path], nil
+		}
+		var sums map[string]string
+		var err error
+		infrastructure.WithIOLimit(func() { sums, err = service.FileHashes(f.Path, []string{"md5"}) })
+		return sums["md5"], err
+	})
+	groups = service.SplitGroups(groups, full)
+	fmt.Printf("🔐 Совпал MD5: %s\n", describeGroups(groups))
+
+	if cfg.ByteCompare {
+		split := make([][][]service.DupCandidate, len(groups))
+		parallelEach(len(groups), cfg.Workers, func(i int) {
+			split[i] = service.SplitByContent(groups[i], func(a, b string) (same bool, err error) {
+				infrastructure.WithIOLimit(func() { same, err = service.SameContent(a, b) })
+				return same, err
+			})
+		})
+		groups = groups[:0]
+		for _, s := range split {
+			groups = append(groups, s...)
+		}
+		fmt.Printf("🔬 Побайтно равны: %s\n", describeGroups(groups))
+	}
+
+	if walkErrs+readErrs > 0 {
+		fmt.Printf("⚠️ Пропущено из-за ошибок: обход %d, чтение %d\n", walkErrs, readErrs)
+	}
+	fmt.Printf("⏱  %v\n\n", time.Since(start))
+	return service.DuplicatesFromGroups(groups, full, cfg.Filter)
+}
+
+// describeGroups — "N файлов в M группах"
+func describeGroups(groups [][]service.DupCandidate) string {
+	n := 0
+	for _, g := range groups {
+		n += len(g)
+	}
+	return fmt.Sprintf("%d файлов в %d группах", n, len(groups))
+}
+
+// parallelEach вызывает fn(0..n-1) на workers горутинах
+func parallelEach(n, workers int, fn func(i int)) {
+	jobs := make(chan int, workers*4)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+	"fsjson/internal/infrastructure"
+)
+
+// Коды выхода проверки целостности (для CI/cron)
+const (
+	IntegrityOK         = 0
+	IntegrityViolations = 1
+	IntegrityError      = 2
+)
+
+// IntegrityMode пересканирует корень эталонного снимка и сообщает о файлах,
+// у которых изменились содержимое, размер, права или владелец,
+// а также о добавленных и удалённых файлах. Возвращает код выхода.
+func IntegrityMode(cfg IntegrityConfig) int {
+	start := time.Now()
+	meta, _, err := infrastructure.ReadSnapshotMeta(cfg.Baseline)
+	if err != nil {
+		fmt.Printf("❌ Ошибка чтения эталона: %v\n", err)
+		return IntegrityError
+	}
+
+	var baseline []model.FileInfo
+	var baseRoot string
+	err = infrastructure.StreamJSONNodes(cfg.Baseline, func(n *model.FileInfo, depth int) error {
+		if depth == 0 && baseRoot == "" {
+			baseRoot = n.FullPath
+		}
+		node := *n
+		node.Children = nil
+		baseline = append(baseline, node)
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("❌ Ошибка чтения эталона: %v\n", err)
+		return IntegrityError
+	}
+
+	root := cfg.RootDir
+	if root == "" {
+		root = baseRoot
+	}
+	exclude := cfg.Exclude
+	if exclude == nil {
+		exclude = meta.Options.Excludes
+	}
+	algo := baselineHashAlgo(meta, baseline)
+
+	fmt.Printf("🛡  Проверка целостности: %s по эталону %s (хеш: %s)\n", root, cfg.Baseline, algo)
+	scanCfg := ScanConfig{
+		RootDir: root,
+		Exclude: exclude,
+		Workers: cfg.Workers,
+		IOLimit: cfg.IOLimit,
+		SkipMD5: algo != "md5",
+	}
+	if algo != "md5" && algo != "" {
+		scanCfg.Hashes = []string{algo}
+	}
+	var errs scanErrors
+	current := scanFlat(scanCfg, &errs, nil)
+
+	report := service.IntegrityReport{
+		Baseline: cfg.Baseline,
+		Root:     root,
+		HashAlgo: algo,
+		Checked:  len(current),
+		Violations: service.CompareToBaseline(
+			service.IndexByRelPath(baseline, baseRoot),
+			service.IndexByRelPath(current, root),
+			algo,
+		),
+	}
+
+	for _, v := range report.Violations {
+		fmt.Printf("❗ %-8s %s\n", v.Kind, v.Path)
+		for _, c := range v.Changes {
+			fmt.Printf("      %s: %s → %s\n", c.Field, c.Expected, c.Actual)
+		}
+	}
+	if cfg.Output != "" {
+		if err := writeIntegrityReport(cfg.Output, report); err != nil {
+			fmt.Printf("❌ Ошибка записи отчёта: %v\n", err)
+			return IntegrityError
+		}
+	}
+
+	if e := errs.snapshot(); e.Total > 0 {
+		fmt.Printf("⚠️  Ошибок при сканировании: %d\n", e.Total)
+	}
+	fmt.Printf("📊 Проверено: %d | нарушений: %d | %v\n", report.Checked, len(report.Violations), time.Since(start))
+	if len(report.Violations) > 0 {
+		return IntegrityViolations
+	}
+	return IntegrityOK
+}
+
+// baselineHashAlgo выбирает самый стойкий алгоритм, посчитанный в эталоне
+func baselineHashAlgo(meta model.Snapshot, nodes []model.FileInfo) string {
+	have := make(map[string]bool)
+	for _, a := range meta.Options.HashAlgorithms {
+		have[a] = true
+	}
+	if meta.Schema == "" { // старый формат: смотрим, что реально есть в узлах
+		for i := range nodes {
+			if !nodes[i].IsDir && nodes[i].Md5 != "" {
+				have["md5"] = true
+			}
+			for a := range nodes[i].Hashes {
+				have[a] = true
+			}
+		}
+	}
+	for _, a := range []string{"sha512", "sha256", "sha1", "md5"} {
+		if have[a] {
+			return a
+		}
+	}
+	return ""
+}
+
+func writeIntegrityReport(output string, report service.IntegrityReport) error {
+	if report.Violations == nil {
+		report.Violations = []service.IntegrityViolation{}
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, append(data, '\n'), 0644)
+}
@@ -0,0 +1,281 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+	"fsjson/internal/infrastructure"
+)
+
+// Статусы проверки файлов
+const (
+	verifyOK      = "OK"
+	verifyFailed  = "FAILED"
+	verifyMissing = "MISSING"
+	verifyExtra   = "EXTRA"
+	verifyError   = "ERROR"
+)
+
+// ExportManifestMode записывает файл контрольных сумм (md5sum/sha256sum или BSD --tag)
+// из хешей, сохранённых в снимке. Возвращает false при ошибке или если у части
+// файлов нет хеша: такой манифест неполон, и проверка по нему пропустит эти файлы.
+func ExportManifestMode(cfg ManifestConfig) bool {
+	algo := cfg.Algo
+	if algo == "" {
+		algo = "md5"
+	}
+	fmt.Printf("🧾 Экспорт контрольных сумм (%s, формат %s): %s → %s\n", algo, cfg.Format, cfg.Snapshot, cfg.Output)
+
+	tmp := cfg.Output + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Println("Ошибка создания временного файла:", err)
+		return false
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	var written, skipped int
+	err = infrastructure.StreamJSONNodes(cfg.Snapshot, func(n *model.FileInfo, _ int) error {
+		if n.IsDir {
+			return nil
+		}
+		h := service.NodeHash(n, algo)
+		if h == "" {
+			skipped++
+			return nil
+		}
+		written++
+		_, err := w.WriteString(service.FormatManifestLine(cfg.Format, algo, h, n.FullPathOrig) + "\n")
+		return err
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		fmt.Println("Ошибка экспорта:", err)
+		_ = os.Remove(tmp)
+		return false
+	}
+	_ = f.Close()
+	_ = os.Rename(tmp, cfg.Output)
+	if err := infrastructure.SignFile(cfg.Output); err != nil {
+		fmt.Println("Ошибка подписи:", err)
+		return false
+	}
+
+	if skipped > 0 {
+		fmt.Printf("⚠️  Записано: %d | без хеша %s: %d — эти файлы в манифест не попали\n", written, algo, skipped)
+		return false
+	}
+	fmt.Printf("✅ Записано: %d\n", written)
+	return true
+}
+
+// Коды результата VerifyManifestMode
+const (
+	VerifyPassed     = 0 // все файлы совпали, лишних нет
+	VerifyMismatch   = 1 // есть расхождения, ошибка или проверять нечего
+	VerifyIncomplete = 2 // расхождений нет, но лишние файлы не искались
+)
+
+// VerifyManifestMode перепроверяет файлы на диске по файлу контрольных сумм
+// или по JSON-снимку и сообщает о несовпадениях, отсутствующих и лишних файлах.
+// Возвращает код результата: VerifyMismatch, если найдено хоть одно расхождение
+// или в источнике нет ни одного хеша, VerifyIncomplete, если поиск лишних файлов
+// пропущен (общий корень не определён).
+func VerifyManifestMode(cfg VerifyManifestConfig) int {
+	entries, root, unhashed, err := loadVerifyEntries(cfg)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return VerifyMismatch
+	}
+	if len(entries) == 0 {
+		fmt.Printf("❌ В %s нет ни одного хеша для проверки (файлов без хеша: %d)\n", cfg.Source, len(unhashed))
+		return VerifyMismatch
+	}
+	fmt.Printf("🔎 Проверка %d файлов по %s (корень: %s)\n", len(entries), cfg.Source, root)
+
+	infrastructure.InitIOLimiter(cfg.IOLimit)
+	statuses := checkEntries(entries, cfg.Workers)
+
+	// лишние файлы — есть на диске, но не перечислены; сам манифест, его подпись
+	// и файлы снимка без хеша лишними не считаются
+	listed := make(map[string]bool, len(entries)+len(unhashed)+2)
+	for _, e := range entries {
+		listed[absPath(e.Path)] = true
+	}
+	for _, p := range unhashed {
+		listed[absPath(p)] = true
+	}
+	listed[absPath(cfg.Source)] = true
+	listed[absPath(infrastructure.SignaturePath(cfg.Source))] = true
+	var extra []string
+	extraChecked := root != "" && root != string(filepath.Separator)
+	if extraChecked {
+		filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+			if err == nil && d.Type().IsRegular() && !listed[absPath(p)] {
+				extra = append(extra, p)
+			}
+			return nil
+		})
+	}
+
+	counts := make(map[string]int)
+	for i, e := range entries {
+		counts[statuses[i]]++
+		if statuses[i] != verifyOK {
+			fmt.Printf("%s: %s\n", e.Path, statuses[i])
+		}
+	}
+	sort.Strings(extra)
+	for _, p := range extra {
+		fmt.Printf("%s: %s\n", p, verifyExtra)
+	}
+	counts[verifyExtra] = len(extra)
+
+	extraCount := fmt.Sprint(counts[verifyExtra])
+	if !extraChecked {
+		extraCount = "не проверялись"
+	}
+	fmt.Printf("📊 OK: %d | FAILED: %d | MISSING: %d | EXTRA: %s | ERROR: %d | без хеша: %d\n",
+		counts[verifyOK], counts[verifyFailed], counts[verifyMissing], extraCount, counts[verifyError], len(unhashed))
+
+	switch {
+	case counts[verifyOK] != len(entries) || len(extra) > 0:
+		return VerifyMismatch
+	case !extraChecked:
+		fmt.Println("⚠️  Общий корень не определён — лишние файлы не искались, проверка неполная")
+		return VerifyIncomplete
+	}
+	return VerifyPassed
+}
+
+// loadVerifyEntries читает ожидаемые хеши и определяет корень для поиска лишних файлов.
+// unhashed — файлы снимка без хеша: проверить их нельзя, но и лишними они не являются.
+func loadVerifyEntries(cfg VerifyManifestConfig) (entries []service.ManifestEntry, root string, unhashed []string, err error) {
+	if infrastructure.SniffJSON(cfg.Source) {
+		algo := cfg.Algo
+		if algo == "" {
+			algo = "md5"
+		}
+		// корень берём из самого дерева: пути узлов записаны в том же виде
+		err = infrastructure.StreamJSONNodes(cfg.Source, func(n *model.FileInfo, depth int) error {
+			if depth == 0 && root == "" && n.IsDir {
+				root = n.FullPath
+			}
+			if n.IsDir {
+				return nil
+			}
+			if h := service.NodeHash(n, algo); h != "" {
+				entries = append(entries, service.ManifestEntry{Algo: algo, Hash: h, Path: n.FullPathOrig})
+			} else {
+				unhashed = append(unhashed, n.FullPathOrig)
+			}
+			return nil
+		})
+		return entries, root, unhashed, err
+	}
+
+	f, err := infrastructure.OpenVerified(cfg.Source)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		e, err := service.ParseManifestLine(text)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("%s:%d: %w", cfg.Source, line, err)
+		}
+		if e.Algo == "" {
+			return nil, "", nil, fmt.Errorf("%s:%d: не удалось определить алгоритм хеша", cfg.Source, line)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, "", nil, err
+	}
+
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.Path
+	}
+	return entries, commonDir(paths), nil, nil
+}
+
+// checkEntries пересчитывает хеши файлов параллельно; статусы — в порядке entries
+func checkEntries(entries []service.ManifestEntry, workers int) []string {
+	if workers < 1 {
+		workers = 1
+	}
+	statuses := make([]string, len(entries))
+	jobs := make(chan int, workers*4)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				e := entries[i]
+				var sums map[string]string
+				var err error
+				infrastructure.WithIOLimit(func() {
+					sums, err = service.FileHashes(e.Path, []string{e.Algo})
+				})
+				switch {
+				case os.IsNotExist(err):
+					statuses[i] = verifyMissing
+				case err != nil:
+					statuses[i] = verifyError
+				case sums[e.Algo] != strings.ToLower(e.Hash):
+					statuses[i] = verifyFailed
+				default:
+					statuses[i] = verifyOK
+				}
+			}
+		}()
+	}
+	for i := range entries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return statuses
+}
+
+// absPath — абсолютный очищенный путь для сравнения путей из манифеста и с диска
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return filepath.Clean(p)
+}
+
+// commonDir — общая родительская директория путей
+func commonDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	dir := filepath.Dir(filepath.Clean(paths[0]))
+	for _, p := range paths[1:] {
+		p = filepath.Clean(p)
+		for dir != "." && dir != string(filepath.Separator) &&
+			!strings.HasPrefix(p, dir+string(filepath.Separator)) {
+			dir = filepath.Dir(dir)
+		}
+	}
+	return dir
+}
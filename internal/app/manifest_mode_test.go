@@ -0,0 +1,61 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func writeSnapshot(t *testing.T, path string, root model.FileInfo) {
+	t.Helper()
+	data, err := json.Marshal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyManifestMode_NothingToVerify(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a")
+	if err := os.WriteFile(file, []byte("hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	snap := filepath.Join(t.TempDir(), "snap.json")
+
+	// снимок с --no-md5: у файла нет хеша — проверка не должна пройти
+	root := model.FileInfo{IsDir: true, FullPath: dir, FullPathOrig: dir, ChildCount: 1,
+		Children: []model.FileInfo{{FullName: "a", FullPath: file, FullPathOrig: file, SizeBytes: 3}}}
+	writeSnapshot(t, snap, root)
+	if code := VerifyManifestMode(VerifyManifestConfig{Source: snap, Algo: "md5", Workers: 1, IOLimit: 1}); code != VerifyMismatch {
+		t.Errorf("без хешей: код %d, ожидался %d", code, VerifyMismatch)
+	}
+
+	root.Children[0].Md5 = "764efa883dda1e11db47671c4a3bbd9e"
+	writeSnapshot(t, snap, root)
+	if code := VerifyManifestMode(VerifyManifestConfig{Source: snap, Algo: "md5", Workers: 1, IOLimit: 1}); code != VerifyPassed {
+		t.Errorf("с хешем: код %d, ожидался %d", code, VerifyPassed)
+	}
+}
+
+func TestVerifyManifestMode_ExtrasSkipped(t *testing.T) {
+	// общий корень путей — "/", лишние файлы не ищутся: проверка неполная
+	a := filepath.Join(t.TempDir(), "a")
+	if err := os.WriteFile(a, []byte("hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := filepath.Join(t.TempDir(), "sums.md5")
+	content := "764efa883dda1e11db47671c4a3bbd9e  " + a + "\n" +
+		"d41d8cd98f00b204e9800998ecf8427e  /dev/null\n"
+	if err := os.WriteFile(manifest, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := VerifyManifestMode(VerifyManifestConfig{Source: manifest, Workers: 1, IOLimit: 1}); code != VerifyIncomplete {
+		t.Errorf("код %d, ожидался %d", code, VerifyIncomplete)
+	}
+}
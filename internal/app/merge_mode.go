@@ -1,11 +1,10 @@
 package app
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"fsjson/internal/domain/model"
 	"fsjson/internal/domain/service"
@@ -13,6 +12,7 @@ import (
 )
 
 func MergeMode(cfg MergeConfig) {
+	start := time.Now()
 	fmt.Printf("🔗 Объединение %d файлов...\n", len(cfg.Files))
 
 	all := make([]model.FileInfo, 0, 10000)
@@ -32,30 +32,32 @@ func MergeMode(cfg MergeConfig) {
 			continue
 		}
 		fmt.Printf("📥 Чтение %s...\n", file)
-		data, err := os.ReadFile(file)
+		snap, err := infrastructure.LoadSnapshot(file)
 		if err != nil {
 			fmt.Printf("❌ Ошибка чтения %s: %v\n", file, err)
+			explainInvalid(file)
 			continue
 		}
-
-		var parsedFlat []model.FileInfo
-		var parsedTree model.FileInfo
+		if snap.Schema != "" {
+			fmt.Printf("📦 %s: конверт v%d (%s, %s)\n", file, snap.Version, snap.Hostname, snap.RootPath)
+		}
 
 		// []FileInfo
-		if err := json.Unmarshal(data, &parsedFlat); err == nil && len(parsedFlat) > 0 {
-			fmt.Printf("📄 %s: flat (%d)\n", file, len(parsedFlat))
-			all = service.AppendFlatUnique(all, parsedFlat, seen)
-			roots = append(roots, service.AssembleNestedFromFlat(parsedFlat))
+		if len(snap.Items) > 0 {
+			fmt.Printf("📄 %s: flat (%d)\n", file, len(snap.Items))
+			all = service.AppendFlatUnique(all, snap.Items, seen)
+			roots = append(roots, service.AssembleNestedFromFlat(snap.Items))
 			continue
 		}
 		// FileInfo
-		if err := json.Unmarshal(data, &parsedTree); err == nil && (parsedTree.FullName != "" || len(parsedTree.Children) > 0) {
-			fmt.Printf("🌲 %s: дерево (%d детей)\n", file, len(parsedTree.Children))
-			all = service.AppendFlatUnique(all, service.FlattenTree(parsedTree), seen)
-			roots = append(roots, parsedTree)
+		if tree := snap.Tree; tree != nil && (tree.FullName != "" || len(tree.Children) > 0) {
+			fmt.Printf("🌲 %s: дерево (%d детей)\n", file, len(tree.Children))
+			all = service.AppendFlatUnique(all, service.FlattenTree(*tree), seen)
+			roots = append(roots, *tree)
 			continue
 		}
 		fmt.Printf("⚠️ %s: неизвестный формат\n", file)
+		explainInvalid(file)
 	}
 
 	if len(all) == 0 && len(roots) == 0 {
@@ -69,7 +71,10 @@ func MergeMode(cfg MergeConfig) {
 		root := service.MergeRootChildren(roots, cfg.Dedupe)
 		service.ComputeDirSizes(&root)
 		service.RecountChildCounts(&root)
-		infrastructure.WriteFinalJSONAtomic(cfg.Output, root, cfg.Pretty)
+		if cfg.Merkle {
+			service.ComputeMerkleHashes(&root, service.TreeHashAlgorithms(&root))
+		}
+		writeMergeResult(cfg, &root, nil, start)
 		infrastructure.DiagnoseJSONShape(cfg.Output)
 		fmt.Printf("✅ Итоговый корень: %s | %s\n", root.FullName, cfg.Output)
 		return
@@ -78,7 +83,7 @@ func MergeMode(cfg MergeConfig) {
 	// Обычная сборка
 	if cfg.MergeFlat {
 		fmt.Println("📤 Сохранение в формате flat ([]FileInfo)")
-		infrastructure.WriteFlatJSONAtomic(cfg.Output, all, cfg.Pretty)
+		writeMergeResult(cfg, nil, all, start)
 		infrastructure.DiagnoseJSONShape(cfg.Output)
 		fmt.Printf("✅ Объединение завершено. Итоговый файл: %s\n", cfg.Output)
 		return
@@ -88,11 +93,47 @@ func MergeMode(cfg MergeConfig) {
 	root := service.AssembleNestedFromFlat(all)
 	service.ComputeDirSizes(&root)
 	service.RecountChildCounts(&root)
-	infrastructure.WriteFinalJSONAtomic(cfg.Output, root, cfg.Pretty)
+	if cfg.Merkle {
+		service.ComputeMerkleHashes(&root, service.TreeHashAlgorithms(&root))
+	}
+	writeMergeResult(cfg, &root, nil, start)
 	infrastructure.DiagnoseJSONShape(cfg.Output)
 	fmt.Printf("✅ Объединение завершено. Итоговый файл: %s\n", cfg.Output)
 }
 
+// writeMergeResult сохраняет результат объединения (дерево или flat),
+// при --envelope — в конверте с метаданными
+func writeMergeResult(cfg MergeConfig, root *model.FileInfo, flat []model.FileInfo, started time.Time) {
+	if !cfg.Envelope {
+		if root != nil {
+			infrastructure.WriteFinalJSONAtomic(cfg.Output, *root, cfg.Pretty)
+		} else {
+			infrastructure.WriteFlatJSONAtomic(cfg.Output, flat, cfg.Pretty)
+		}
+		return
+	}
+	snap := newSnapshot("", started)
+	if cfg.Merkle && root != nil {
+		snap.Options.DirHash = model.DirHashMerkle
+	}
+	if root != nil {
+		snap.RootPath = root.FullPath
+		snap.Counts = service.CountTree(root)
+		snap.Tree = root
+	} else {
+		for i := range flat {
+			if flat[i].IsDir {
+				snap.Counts.Dirs++
+			} else {
+				snap.Counts.Files++
+				snap.Counts.Bytes += flat[i].SizeBytes
+			}
+		}
+		snap.Items = flat
+	}
+	infrastructure.WriteSnapshotJSONAtomic(cfg.Output, snap, cfg.Pretty)
+}
+
 // --- вспомогательные функции сортировки ---
 func sortRoots(roots []model.FileInfo) {
 	sort.Slice(roots, func(i, j int) bool {
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"fsjson/internal/domain/service"
+	"fsjson/internal/infrastructure"
+)
+
+// ExportNcduMode записывает снимок в формате дампа ncdu (ncdu -f файл);
+// "-" — в stdout, для ncdu -f-. Плоский снимок сначала собирается в дерево.
+func ExportNcduMode(cfg NcduConfig) error {
+	_, shape, err := infrastructure.ReadSnapshotMeta(cfg.Snapshot)
+	if err != nil {
+		return err
+	}
+	src := infrastructure.FileSource(cfg.Snapshot)
+	if shape == infrastructure.ShapeFlat {
+		root, err := infrastructure.ReadTreeJSON(cfg.Snapshot)
+		if err != nil {
+			return err
+		}
+		src = service.TreeSource(&root)
+	}
+
+	if cfg.Output == "-" {
+		_, err := service.WriteNcdu(os.Stdout, src, Version, time.Now())
+		return err
+	}
+	fmt.Printf("📤 Экспорт в формат ncdu: %s → %s\n", cfg.Snapshot, cfg.Output)
+	var counts service.NcduCounts
+	err = writeFileAtomic(cfg.Output, func(w io.Writer) error {
+		counts, err = service.WriteNcdu(w, src, Version, time.Now())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Записано: %d файлов, %d директорий. Просмотр: ncdu -f %s\n", counts.Files, counts.Dirs, cfg.Output)
+	return nil
+}
+
+// writeFileAtomic пишет файл через временный и переименование
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err = write(f); err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -22,12 +22,33 @@ func ProcessParallel(cfg ScanConfig) {
 	fmt.Printf("⚙️  Workers: %d | I/O limit: %d | MD5: %v | pretty: %v\n",
 		cfg.Workers, cfg.IOLimit, !cfg.SkipMD5, cfg.Pretty)
 
+	var errs scanErrors
+	idx := newContentIndex(cfg)
+	flat := scanFlat(cfg, &errs, idx)
+	processed := len(flat)
+
+	root := service.AssembleNestedFromFlat(flat)
+	service.ComputeDirSizes(&root)
+	if cfg.Merkle {
+		service.ComputeMerkleHashes(&root, cfg.hashAlgorithms())
+	}
+	writeScanResult(cfg, root, start, &errs, false)
+	writeContentIndex(cfg, idx)
+	infrastructure.DiagnoseJSONShape(cfg.Output)
+
+	fmt.Printf("✅ Готово. Файлов: %d | %v\n", processed, time.Since(start))
+}
+
+// scanFlat обходит cfg.RootDir параллельными воркерами и возвращает плоский список узлов;
+// idx (может быть nil) получает содержимое текстовых файлов
+func scanFlat(cfg ScanConfig, errs *scanErrors, idx *service.ContentIndexBuilder) []model.FileInfo {
 	infrastructure.InitIOLimiter(cfg.IOLimit)
 
 	jobs := make(chan string, cfg.Workers*4)
 	results := make(chan model.FileInfo, cfg.Workers*4)
 	var wg sync.WaitGroup
 	var processed int64
+	build := entryBuilder(cfg, errs, idx)
 
 	for i := 0; i < cfg.Workers; i++ {
 		wg.Add(1)
@@ -39,21 +60,10 @@ func ProcessParallel(cfg ScanConfig) {
 				}
 				fi, err := os.Stat(path)
 				if err != nil {
+					atomic.AddInt64(&errs.stat, 1)
 					continue
 				}
-				entry := service.ProcessPathWith(path, fi, cfg.SkipMD5,
-					func(dir string) int {
-						return infrastructure.WithIOLimitValue(func() int {
-							list, _ := os.ReadDir(dir)
-							return len(list)
-						})
-					},
-					func(p string) string {
-						return infrastructure.WithIOLimitValue(func() string {
-							return service.FileMD5(p)
-						})
-					},
-				)
+				entry := build(path, fi)
 				if entry.FullName != "" {
 					results <- entry
 				}
@@ -64,9 +74,11 @@ func ProcessParallel(cfg ScanConfig) {
 	go func() {
 		defer close(jobs)
 		filepath.WalkDir(cfg.RootDir, func(path string, d os.DirEntry, err error) error {
-			if err == nil {
-				jobs <- path
+			if err != nil {
+				atomic.AddInt64(&errs.walk, 1)
+				return nil
 			}
+			jobs <- path
 			return nil
 		})
 	}()
@@ -86,12 +98,7 @@ func ProcessParallel(cfg ScanConfig) {
 		}
 	}
 
-	root := service.AssembleNestedFromFlat(flat)
-	service.ComputeDirSizes(&root)
-	infrastructure.WriteFinalJSONAtomic(cfg.Output, root, cfg.Pretty)
-	infrastructure.DiagnoseJSONShape(cfg.Output)
-
-	fmt.Printf("✅ Готово. Файлов: %d | %v\n", processed, time.Since(start))
+	return flat
 }
 
 func printProgress(n int64) {
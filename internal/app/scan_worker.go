@@ -0,0 +1,104 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+	"fsjson/internal/infrastructure"
+)
+
+// entryBuilder возвращает функцию построения FileInfo для воркеров сканирования:
+// с I/O-лимитом, дополнительными хешами (--hash), хешем изображений (--image-hash),
+// индексом содержимого (--content-index, idx != nil) и подсчётом ошибок
+func entryBuilder(cfg ScanConfig, errs *scanErrors, idx *service.ContentIndexBuilder) func(path string, fi os.FileInfo) model.FileInfo {
+	readDirCount := func(dir string) int {
+		return infrastructure.WithIOLimitValue(func() int {
+			list, _ := os.ReadDir(dir)
+			return len(list)
+		})
+	}
+	fileMD5 := func(p string) string {
+		sum := infrastructure.WithIOLimitValue(func() string {
+			return service.FileMD5(p)
+		})
+		if sum == "" {
+			atomic.AddInt64(&errs.hash, 1)
+		}
+		return sum
+	}
+	algos := cfg.hashAlgorithms()
+
+	build := func(path string, fi os.FileInfo) model.FileInfo {
+		if len(cfg.Hashes) == 0 || fi.IsDir() {
+			return service.ProcessPathWith(path, fi, cfg.SkipMD5, readDirCount, fileMD5)
+		}
+		// несколько алгоритмов — считаем все за одно чтение файла
+		entry := service.ProcessPathWith(path, fi, true, readDirCount, nil)
+		var sums map[string]string
+		var err error
+		infrastructure.WithIOLimit(func() {
+			sums, err = service.FileHashes(path, algos)
+		})
+		if err != nil {
+			atomic.AddInt64(&errs.hash, 1)
+			return entry
+		}
+		service.SetNodeHashes(&entry, sums)
+		return entry
+	}
+	if cfg.ImageHash != "" {
+		// перцептивный хеш: файлы, которые не удалось декодировать, просто остаются без него;
+		// слишком большие не декодируются вовсе — о них предупреждаем
+		withHash := build
+		build = func(path string, fi os.FileInfo) model.FileInfo {
+			entry := withHash(path, fi)
+			if entry.FileType == "image" && service.CanImageHash(entry.Ext) {
+				var err error
+				infrastructure.WithIOLimit(func() {
+					entry.ImageHash, err = service.ImageFileHash(path, cfg.ImageHash)
+				})
+				if errors.Is(err, service.ErrImageTooLarge) {
+					fmt.Printf("⚠️ %s: %v — пропущено\n", path, err)
+				}
+			}
+			return entry
+		}
+	}
+	if idx == nil {
+		return build
+	}
+
+	// индекс содержимого: большие, двоичные и не-UTF-8 файлы пропускаются
+	return func(path string, fi os.FileInfo) model.FileInfo {
+		entry := build(path, fi)
+		if !entry.IsDir && service.CanIndexContent(entry.FileType) && entry.SizeBytes <= idx.MaxSize() {
+			infrastructure.WithIOLimit(func() {
+				idx.AddFile(entry.FullPathOrig)
+			})
+		}
+		return entry
+	}
+}
+
+// newContentIndex — индексатор содержимого, если он включён
+func newContentIndex(cfg ScanConfig) *service.ContentIndexBuilder {
+	if !cfg.ContentIndex {
+		return nil
+	}
+	return service.NewContentIndexBuilder(cfg.ContentMaxSize)
+}
+
+// writeContentIndex сохраняет индекс содержимого рядом с результатом сканирования
+func writeContentIndex(cfg ScanConfig, idx *service.ContentIndexBuilder) {
+	if idx == nil {
+		return
+	}
+	out := infrastructure.ContentIndexPath(cfg.Output)
+	built := idx.Build()
+	infrastructure.WriteContentIndexAtomic(out, built)
+	fmt.Printf("📚 Индекс содержимого: %s | файлов: %d, слов: %d\n", out, len(built.Files), len(built.Terms))
+}
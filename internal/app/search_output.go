@@ -0,0 +1,208 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fsjson/internal/domain/service"
+)
+
+// Форматы вывода результатов поиска
+const (
+	OutputText   = "text"   // строка на результат и сводка (по умолчанию)
+	OutputJSON   = "json"   // весь ответ: results, stats, total, next_cursor
+	OutputNDJSON = "ndjson" // объект на строку
+	OutputCSV    = "csv"    // заголовок и строка на результат
+	OutputPaths0 = "paths0" // пути через NUL, для xargs -0
+)
+
+// SearchFields — поля результата для --fields, в порядке по умолчанию
+var SearchFields = []string{"path", "name", "size", "type", "modified", "created"}
+
+// ParseOutputFormat проверяет формат вывода ("" — text)
+func ParseOutputFormat(s string) (string, error) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "":
+		return OutputText, nil
+	case OutputText, OutputJSON, OutputNDJSON, OutputCSV, OutputPaths0:
+		return s, nil
+	}
+	return "", fmt.Errorf("неизвестный формат вывода %q (поддерживаются: text, json, ndjson, csv, paths0)", s)
+}
+
+// ParseSearchFields разбирает список полей через запятую ("" — формат по умолчанию)
+// и проверяет, что формат вывода их поддерживает: paths0 выводит только пути.
+// Проверка до поиска — чтобы не искать впустую.
+func ParseSearchFields(s, format string) ([]string, error) {
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if !slices.Contains(SearchFields, f) {
+			return nil, fmt.Errorf("неизвестное поле %q (поддерживаются: %s)", f, strings.Join(SearchFields, ", "))
+		}
+		out = append(out, f)
+	}
+	if len(out) > 0 && format == OutputPaths0 {
+		return nil, fmt.Errorf("--fields не применяется к формату paths0: выводятся только пути")
+	}
+	return out, nil
+}
+
+// WriteSearchResults выводит результаты поиска в out. В машинных форматах, кроме json,
+// сводка (количество, курсор следующей страницы) пишется в info, чтобы не смешиваться с данными.
+// fields — результат ParseSearchFields для того же формата.
+func WriteSearchResults(out, info io.Writer, res service.SearchResponse, format string, fields []string) error {
+	w := bufio.NewWriter(out)
+	var err error
+	switch format {
+	case OutputJSON:
+		err = writeSearchJSON(w, res, fields)
+	case OutputNDJSON:
+		enc := json.NewEncoder(w)
+		for _, r := range res.Results {
+			if err = enc.Encode(searchRow(r, fields)); err != nil {
+				break
+			}
+		}
+	case OutputCSV:
+		err = writeSearchCSV(w, res.Results, fields)
+	case OutputPaths0:
+		for _, r := range res.Results {
+			w.WriteString(r.FullPathOrig)
+			w.WriteByte(0)
+		}
+	default:
+		for _, r := range res.Results {
+			if len(fields) == 0 {
+				fmt.Fprintf(w, "%s (%s, %d bytes)\n", r.FullPathOrig, r.FileType, r.SizeBytes)
+				for _, s := range r.Snippets {
+					fmt.Fprintf(w, "    %5d: %s\n", s.Line, s.Text)
+				}
+				continue
+			}
+			fmt.Fprintln(w, strings.Join(fieldStrings(r, fields), "\t"))
+		}
+		info = w
+	}
+	if err != nil {
+		return err
+	}
+	if format != OutputJSON {
+		fmt.Fprintf(info, "🔍 Найдено %d элементов\n", res.Total)
+		if res.NextCursor != "" {
+			fmt.Fprintf(info, "➡️  Следующая страница: --cursor=%s\n", res.NextCursor)
+		}
+	}
+	return w.Flush()
+}
+
+// writeSearchJSON — ответ целиком; с --fields в results только выбранные поля
+func writeSearchJSON(w io.Writer, res service.SearchResponse, fields []string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if len(fields) == 0 {
+		return enc.Encode(res)
+	}
+	rows := make([]any, len(res.Results))
+	for i, r := range res.Results {
+		rows[i] = searchRow(r, fields)
+	}
+	return enc.Encode(struct {
+		Results    []any               `json:"results"`
+		Stats      service.SearchStats `json:"stats"`
+		Total      int                 `json:"total"`
+		NextCursor string              `json:"next_cursor,omitempty"`
+	}{rows, res.Stats, res.Total, res.NextCursor})
+}
+
+func writeSearchCSV(w io.Writer, results []service.SearchResult, fields []string) error {
+	if len(fields) == 0 {
+		fields = SearchFields
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(fields)
+	for _, r := range results {
+		cw.Write(fieldStrings(r, fields))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// searchRow — результат для json/ndjson: без --fields как есть, иначе только выбранные поля по порядку
+func searchRow(r service.SearchResult, fields []string) any {
+	if len(fields) == 0 {
+		return r
+	}
+	return orderedRow{fields: fields, r: r}
+}
+
+// orderedRow сохраняет порядок полей из --fields (map отсортировал бы ключи)
+type orderedRow struct {
+	fields []string
+	r      service.SearchResult
+}
+
+func (o orderedRow) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range o.fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(f)
+		val, err := json.Marshal(fieldValue(o.r, f))
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(val)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func fieldValue(r service.SearchResult, field string) any {
+	switch field {
+	case "path":
+		return r.FullPathOrig
+	case "name":
+		return filepath.Base(r.FullPathOrig)
+	case "size":
+		return r.SizeBytes
+	case "type":
+		return r.FileType
+	case "modified":
+		return r.Modified
+	case "created":
+		return r.Created
+	}
+	return nil
+}
+
+func fieldStrings(r service.SearchResult, fields []string) []string {
+	out := make([]string, len(fields))
+	for i, f := range fields {
+		switch v := fieldValue(r, f).(type) {
+		case string:
+			out[i] = v
+		case int64:
+			out[i] = strconv.FormatInt(v, 10)
+		case time.Time:
+			out[i] = v.Format(time.RFC3339)
+		}
+	}
+	return out
+}
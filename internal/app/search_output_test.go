@@ -0,0 +1,98 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"fsjson/internal/domain/service"
+)
+
+func searchOutputFixture() service.SearchResponse {
+	mod := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	return service.SearchResponse{
+		Results: []service.SearchResult{
+			{FullPathOrig: "/r/plain.txt", SizeBytes: 10, FileType: "text", Modified: mod},
+			{FullPathOrig: "/r/a, \"b\".txt", SizeBytes: 20, FileType: "text", Modified: mod},
+			{FullPathOrig: "/r/line\nbreak.txt", SizeBytes: 30, FileType: "text", Modified: mod},
+		},
+		Stats:      service.SearchStats{"text": 3},
+		Total:      7,
+		NextCursor: "abc",
+	}
+}
+
+func TestWriteSearchResults(t *testing.T) {
+	cases := []struct {
+		name, format, fields string
+		out, info            string
+	}{
+		{
+			name: "csv: кавычки, запятые и переводы строк", format: OutputCSV, fields: "path,size",
+			out:  "path,size\n/r/plain.txt,10\n\"/r/a, \"\"b\"\".txt\",20\n\"/r/line\nbreak.txt\",30\n",
+			info: "🔍 Найдено 7 элементов\n➡️  Следующая страница: --cursor=abc\n",
+		},
+		{
+			name: "paths0: пути через NUL", format: OutputPaths0,
+			out:  "/r/plain.txt\x00/r/a, \"b\".txt\x00/r/line\nbreak.txt\x00",
+			info: "🔍 Найдено 7 элементов\n➡️  Следующая страница: --cursor=abc\n",
+		},
+		{
+			name: "ndjson: поля в порядке --fields", format: OutputNDJSON, fields: "size,path,modified",
+			out: `{"size":10,"path":"/r/plain.txt","modified":"2025-03-01T12:00:00Z"}` + "\n" +
+				`{"size":20,"path":"/r/a, \"b\".txt","modified":"2025-03-01T12:00:00Z"}` + "\n" +
+				`{"size":30,"path":"/r/line\nbreak.txt","modified":"2025-03-01T12:00:00Z"}` + "\n",
+			info: "🔍 Найдено 7 элементов\n➡️  Следующая страница: --cursor=abc\n",
+		},
+		{
+			name: "text: сводка вместе с результатами", format: OutputText, fields: "name,size",
+			out: "plain.txt\t10\na, \"b\".txt\t20\nline\nbreak.txt\t30\n" +
+				"🔍 Найдено 7 элементов\n➡️  Следующая страница: --cursor=abc\n",
+		},
+	}
+	for _, c := range cases {
+		fields, err := ParseSearchFields(c.fields, c.format)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		var out, info bytes.Buffer
+		if err := WriteSearchResults(&out, &info, searchOutputFixture(), c.format, fields); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if out.String() != c.out {
+			t.Errorf("%s: вывод\n%q\nожидался\n%q", c.name, out.String(), c.out)
+		}
+		if info.String() != c.info {
+			t.Errorf("%s: сводка %q, ожидалась %q", c.name, info.String(), c.info)
+		}
+	}
+}
+
+func TestWriteSearchResults_JSON(t *testing.T) {
+	var out, info bytes.Buffer
+	fields, _ := ParseSearchFields("path", OutputJSON)
+	if err := WriteSearchResults(&out, &info, searchOutputFixture(), OutputJSON, fields); err != nil {
+		t.Fatal(err)
+	}
+	if info.Len() != 0 {
+		t.Errorf("в json сводка — часть ответа, а не отдельный вывод: %q", info.String())
+	}
+	for _, want := range []string{`"path": "/r/plain.txt"`, `"total": 7`, `"next_cursor": "abc"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("в ответе нет %s:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestParseSearchFields(t *testing.T) {
+	if _, err := ParseSearchFields("path", OutputPaths0); err == nil {
+		t.Error("--fields с paths0 должны отклоняться до поиска")
+	}
+	if _, err := ParseSearchFields("", OutputPaths0); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParseSearchFields("path,owner", OutputCSV); err == nil {
+		t.Error("ожидалась ошибка для неизвестного поля")
+	}
+}
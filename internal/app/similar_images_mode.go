@@ -0,0 +1,24 @@
+package app
+
+import (
+	"fmt"
+
+	"fsjson/internal/domain/service"
+)
+
+// PrintSimilarImages печатает кластеры визуально похожих изображений
+func PrintSimilarImages(res service.SimilarImagesResponse) {
+	if res.Hashed == 0 {
+		fmt.Println("⚠️ В снимке нет хешей изображений — сканируйте с --image-hash=ahash|dhash|phash")
+		return
+	}
+	fmt.Printf("🖼️ Изображений с хешем: %d, групп похожих: %d (расстояние ≤ %d бит)\n\n",
+		res.Hashed, len(res.Clusters), res.MaxDistance)
+	for _, c := range res.Clusters {
+		fmt.Printf("🧩 %d изображений (%s)\n", len(c.Images), c.Algo)
+		for _, img := range c.Images {
+			fmt.Printf("   %2d  %10s  %s\n", img.Distance, service.HumanSize(img.Size), img.Path)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+	"fsjson/internal/infrastructure"
+)
+
+// Version — версия утилиты, задаётся при сборке:
+//
+//	go build -ldflags "-X fsjson/internal/app.Version=1.2.3" ./cmd/fsjson
+var Version = "dev"
+
+// scanErrors — счётчики ошибок, которые сканирование пропускает
+type scanErrors struct {
+	walk int64
+	stat int64
+	hash int64
+}
+
+func (e *scanErrors) snapshot() model.SnapshotErrors {
+	s := model.SnapshotErrors{
+		Walk: atomic.LoadInt64(&e.walk),
+		Stat: atomic.LoadInt64(&e.stat),
+		Hash: atomic.LoadInt64(&e.hash),
+	}
+	s.Total = s.Walk + s.Stat + s.Hash
+	return s
+}
+
+// newSnapshot заполняет общие метаданные конверта
+func newSnapshot(rootPath string, started time.Time) model.Snapshot {
+	host, _ := os.Hostname()
+	return model.Snapshot{
+		ToolVersion:  Version,
+		Hostname:     host,
+		RootPath:     rootPath,
+		ScanStarted:  started,
+		ScanFinished: time.Now(),
+	}
+}
+
+// writeScanResult сохраняет результат сканирования: в конверте (--envelope) или голым деревом
+func writeScanResult(cfg ScanConfig, root model.FileInfo, started time.Time, errs *scanErrors, stream bool) {
+	if !cfg.Envelope {
+		infrastructure.WriteFinalJSONAtomic(cfg.Output, root, cfg.Pretty)
+		return
+	}
+	rootAbs, _ := filepath.Abs(cfg.RootDir)
+	snap := newSnapshot(rootAbs, started)
+	snap.Options = model.SnapshotOptions{
+		Workers:        cfg.Workers,
+		IOLimit:        cfg.IOLimit,
+		Excludes:       cfg.Exclude,
+		HashAlgorithms: cfg.hashAlgorithms(),
+		Stream:         stream,
+	}
+	if cfg.Merkle {
+		snap.Options.DirHash = model.DirHashMerkle
+	}
+	snap.Options.ImageHash = cfg.ImageHash
+	if cfg.ContentIndex {
+		snap.Options.ContentIndex = filepath.Base(infrastructure.ContentIndexPath(cfg.Output))
+	}
+	snap.Counts = service.CountTree(&root)
+	snap.Errors = errs.snapshot()
+	snap.Tree = &root
+	infrastructure.WriteSnapshotJSONAtomic(cfg.Output, snap, cfg.Pretty)
+}
+
+// hashAlgorithms — алгоритмы хеширования содержимого, включённые для сканирования
+func (cfg ScanConfig) hashAlgorithms() []string {
+	algos := []string{}
+	if !cfg.SkipMD5 {
+		algos = append(algos, "md5")
+	}
+	for _, a := range cfg.Hashes {
+		if a != "md5" || cfg.SkipMD5 {
+			algos = append(algos, a)
+		}
+	}
+	return algos
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"fsjson/internal/domain/service"
+)
+
+// maxStatsKeyWidth — ширина колонки группы в текстовой таблице
+const maxStatsKeyWidth = 60
+
+// WriteStats выводит статистику: text — таблица, json — весь ответ,
+// ndjson — группа на строку, csv — поля группировки и метрики
+func WriteStats(out io.Writer, res service.StatsResponse, format string) error {
+	w := bufio.NewWriter(out)
+	var err error
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(res)
+	case OutputNDJSON:
+		enc := json.NewEncoder(w)
+		for _, g := range res.Groups {
+			if err = enc.Encode(g); err != nil {
+				break
+			}
+		}
+	case OutputCSV:
+		err = writeStatsCSV(w, res)
+	case OutputText:
+		writeStatsText(w, res)
+	default:
+		return fmt.Errorf("формат %s не поддерживается для статистики (text, json, ndjson, csv)", format)
+	}
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func writeStatsText(w io.Writer, res service.StatsResponse) {
+	fmt.Fprintf(w, "📊 Файлов: %d, всего %s", res.Total.Count, service.HumanSize(res.Total.Sum))
+	if len(res.GroupBy) > 0 {
+		fmt.Fprintf(w, ", группировка: %s", strings.Join(res.GroupBy, ", "))
+	}
+	fmt.Fprintln(w)
+	if len(res.GroupBy) == 0 || len(res.Groups) == 0 {
+		return
+	}
+
+	keys := make([]string, len(res.Groups))
+	width := len("Группа")
+	for i, g := range res.Groups {
+		keys[i] = statsKeyText(g.Key)
+		width = min(max(width, utf8.RuneCountInString(keys[i])), maxStatsKeyWidth)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "   %s  %8s  %10s  %10s  %10s  %10s\n", padRight("Группа", width), "Файлов", "Всего", "Мин.", "Макс.", "Сред.")
+	for i, g := range res.Groups {
+		fmt.Fprintf(w, "   %s  %8d  %10s  %10s  %10s  %10s\n", padRight(keys[i], width), g.Count,
+			service.HumanSize(g.Sum), service.HumanSize(g.Min), service.HumanSize(g.Max), service.HumanSize(int64(g.Avg)))
+	}
+	if len(res.Groups) < res.Count {
+		fmt.Fprintf(w, "\n📄 Показаны %d групп из %d (--limit)\n", len(res.Groups), res.Count)
+	}
+}
+
+// statsKeyText — значения полей группы через " | "; пустое значение — "—"
+func statsKeyText(key []string) string {
+	parts := make([]string, len(key))
+	for i, k := range key {
+		parts[i] = k
+		if k == "" {
+			parts[i] = "—"
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// padRight дополняет пробелами до width символов, длинное обрезает с «…» в начале
+// (у путей важнее конец)
+func padRight(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		r := []rune(s)
+		return "…" + string(r[n-width+1:])
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+func writeStatsCSV(w io.Writer, res service.StatsResponse) error {
+	cw := csv.NewWriter(w)
+	header := append(append([]string{}, res.GroupBy...), "count", "sum", "min", "max", "avg")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, g := range res.Groups {
+		row := append(append([]string{}, g.Key...),
+			strconv.FormatInt(g.Count, 10), strconv.FormatInt(g.Sum, 10),
+			strconv.FormatInt(g.Min, 10), strconv.FormatInt(g.Max, 10),
+			strconv.FormatFloat(g.Avg, 'f', 1, 64))
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
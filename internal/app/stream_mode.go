@@ -44,6 +44,9 @@ func ProcessParallelStream(cfg ScanConfig) {
 
 	var wg sync.WaitGroup
 	var processed int64
+	var errs scanErrors
+	idx := newContentIndex(cfg)
+	build := entryBuilder(cfg, &errs, idx)
 
 	// Воркеры
 	for i := 0; i < cfg.Workers; i++ {
@@ -57,22 +60,11 @@ func ProcessParallelStream(cfg ScanConfig) {
 				}
 				fi, err := os.Stat(path)
 				if err != nil {
+					atomic.AddInt64(&errs.stat, 1)
 					continue
 				}
-				// инъекция I/O-ограничений в ReadDir и FileMD5
-				entry := service.ProcessPathWith(path, fi, cfg.SkipMD5,
-					func(dir string) int {
-						return infrastructure.WithIOLimitValue(func() int {
-							list, _ := os.ReadDir(dir)
-							return len(list)
-						})
-					},
-					func(p string) string {
-						return infrastructure.WithIOLimitValue(func() string {
-							return service.FileMD5(p)
-						})
-					},
-				)
+				// I/O-ограничения и хеши — в entryBuilder
+				entry := build(path, fi)
 				if entry.FullName != "" {
 					results <- entry
 				}
@@ -107,9 +99,11 @@ func ProcessParallelStream(cfg ScanConfig) {
 	go func() {
 		defer close(jobs)
 		filepath.WalkDir(cfg.RootDir, func(path string, d os.DirEntry, err error) error {
-			if err == nil {
-				jobs <- path
+			if err != nil {
+				atomic.AddInt64(&errs.walk, 1)
+				return nil
 			}
+			jobs <- path
 			return nil
 		})
 	}()
@@ -128,7 +122,11 @@ func ProcessParallelStream(cfg ScanConfig) {
 	}
 	root := service.AssembleNestedFromFlat(flat)
 	service.ComputeDirSizes(&root)
-	infrastructure.WriteFinalJSONAtomic(cfg.Output, root, cfg.Pretty)
+	if cfg.Merkle {
+		service.ComputeMerkleHashes(&root, cfg.hashAlgorithms())
+	}
+	writeScanResult(cfg, root, start, &errs, true)
+	writeContentIndex(cfg, idx)
 	infrastructure.DiagnoseJSONShape(cfg.Output)
 
 	fmt.Printf("🎉 Завершено. Файлов: %d | %v\n", processed, time.Since(start))
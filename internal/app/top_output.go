@@ -0,0 +1,53 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"fsjson/internal/domain/service"
+)
+
+// WriteTop выводит списки --top: text — по разделу на список, json — весь ответ
+func WriteTop(out io.Writer, res service.TopResponse, format string) error {
+	w := bufio.NewWriter(out)
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	case OutputText:
+		writeTopText(w, res)
+	default:
+		return fmt.Errorf("формат %s не поддерживается для --top (text, json)", format)
+	}
+	return w.Flush()
+}
+
+func writeTopText(w io.Writer, res service.TopResponse) {
+	sections := []struct {
+		title string
+		list  []service.TopEntry
+		value func(e service.TopEntry) string
+	}{
+		{"📦 Самые большие файлы:", res.Files, func(e service.TopEntry) string { return service.HumanSize(e.Size) }},
+		{"📂 Самые большие директории:", res.Dirs, func(e service.TopEntry) string { return service.HumanSize(e.Size) }},
+		{"🗃️  Больше всего элементов:", res.Crowded, func(e service.TopEntry) string { return fmt.Sprintf("%d", e.Entries) }},
+		{"🕳️  Самые глубокие пути:", res.Deepest, func(e service.TopEntry) string { return fmt.Sprintf("ур. %d", e.Depth) }},
+	}
+	for i, s := range sections {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, s.title)
+		if len(s.list) == 0 {
+			fmt.Fprintln(w, "   —")
+		}
+		for _, e := range s.list {
+			fmt.Fprintf(w, "   %10s  %s\n", s.value(e), e.Path)
+		}
+	}
+}
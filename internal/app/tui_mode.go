@@ -0,0 +1,395 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+	"fsjson/internal/infrastructure"
+)
+
+// Escape-последовательности ANSI, которые использует TUI
+const (
+	ansiAltScreenOn  = "\x1b[?1049h"
+	ansiAltScreenOff = "\x1b[?1049l"
+	ansiHideCursor   = "\x1b[?25l"
+	ansiShowCursor   = "\x1b[?25h"
+	ansiHome         = "\x1b[H"
+	ansiClearLine    = "\x1b[K"
+	ansiClearBelow   = "\x1b[J"
+	ansiReverse      = "\x1b[7m"
+	ansiBold         = "\x1b[1m"
+	ansiDim          = "\x1b[2m"
+	ansiReset        = "\x1b[0m"
+)
+
+const (
+	tuiBarWidth      = 10
+	tuiTypeRows      = 6   // строк в панели типов
+	tuiSearchResults = 500 // сколько результатов поиска показывать
+)
+
+// tuiState — состояние просмотра: текущая директория или результаты поиска
+type tuiState struct {
+	root    *model.FileInfo
+	parents map[*model.FileInfo]*model.FileInfo
+	byPath  map[string]*model.FileInfo // FullPathOrig → узел, для перехода к результату поиска
+
+	dir         *model.FileInfo
+	items       []*model.FileInfo
+	cursor, top int
+	sortBy      string
+
+	showTypes bool
+	types     map[*model.FileInfo][]service.TypeShare
+
+	searching bool   // вводится запрос
+	query     []rune // текст запроса
+	results   bool   // в items — результаты поиска, а не содержимое dir
+	status    string
+
+	cols, rows int
+	quit       bool
+}
+
+// TUIMode — просмотр снимка в терминале, как в ncdu: директории по размеру, переход
+// внутрь и назад, поиск по имени (нечёткий), разбивка по типам файлов для директории
+func TUIMode(cfg TUIConfig) error {
+	fmt.Fprintf(os.Stderr, "📄 Загрузка %s…\n", cfg.File)
+	root, err := infrastructure.ReadTreeJSON(cfg.File)
+	if err != nil {
+		return err
+	}
+	term, err := infrastructure.OpenTerminal()
+	if err != nil {
+		return err
+	}
+	defer term.Restore()
+
+	s := newTUIState(&root)
+	keys := make(chan infrastructure.Key, 16)
+	go infrastructure.ReadKeys(os.Stdin, keys)
+
+	out := os.Stdout
+	fmt.Fprint(out, ansiAltScreenOn+ansiHideCursor)
+	defer fmt.Fprint(out, ansiReset+ansiShowCursor+ansiAltScreenOff)
+	for !s.quit {
+		if cols, rows, err := term.Size(); err == nil && cols > 0 && rows > 0 {
+			s.cols, s.rows = cols, rows
+		}
+		fmt.Fprint(out, s.render())
+		select {
+		case k := <-keys:
+			s.handle(k)
+		case <-term.Resized():
+		}
+	}
+	return nil
+}
+
+func newTUIState(root *model.FileInfo) *tuiState {
+	s := &tuiState{
+		root:    root,
+		parents: make(map[*model.FileInfo]*model.FileInfo),
+		byPath:  make(map[string]*model.FileInfo),
+		types:   make(map[*model.FileInfo][]service.TypeShare),
+		sortBy:  service.BrowseBySize,
+		cols:    80,
+		rows:    24,
+	}
+	var index func(n *model.FileInfo)
+	index = func(n *model.FileInfo) {
+		s.byPath[n.FullPathOrig] = n
+		for i := range n.Children {
+			s.parents[&n.Children[i]] = n
+			index(&n.Children[i])
+		}
+	}
+	index(root)
+	s.open(root, nil)
+	return s
+}
+
+// open показывает директорию; курсор — на элементе selected, если он в ней есть
+func (s *tuiState) open(dir *model.FileInfo, selected *model.FileInfo) {
+	s.dir, s.results, s.cursor, s.top = dir, false, 0, 0
+	s.items = service.BrowseChildren(dir, s.sortBy)
+	for i, n := range s.items {
+		if n == selected {
+			s.cursor = i
+		}
+	}
+}
+
+func (s *tuiState) listRows() int {
+	rows := s.rows - 3 // заголовок, строка колонок и подсказка внизу
+	if s.showTypes {
+		rows -= tuiTypeRows + 1
+	}
+	return max(rows, 1)
+}
+
+func (s *tuiState) handle(k infrastructure.Key) {
+	if s.searching {
+		s.handleSearchInput(k)
+		return
+	}
+	s.status = ""
+	page := s.listRows()
+	switch {
+	case k.Code == infrastructure.KeyCtrlC, k.Code == infrastructure.KeyRune && k.Rune == 'q':
+		s.quit = true
+	case k.Code == infrastructure.KeyUp, k.Code == infrastructure.KeyRune && k.Rune == 'k':
+		s.move(-1)
+	case k.Code == infrastructure.KeyDown, k.Code == infrastructure.KeyRune && k.Rune == 'j':
+		s.move(1)
+	case k.Code == infrastructure.KeyPageUp:
+		s.move(-page)
+	case k.Code == infrastructure.KeyPageDown:
+		s.move(page)
+	case k.Code == infrastructure.KeyHome, k.Code == infrastructure.KeyRune && k.Rune == 'g':
+		s.move(-len(s.items))
+	case k.Code == infrastructure.KeyEnd, k.Code == infrastructure.KeyRune && k.Rune == 'G':
+		s.move(len(s.items))
+	case k.Code == infrastructure.KeyEnter, k.Code == infrastructure.KeyRight, k.Code == infrastructure.KeyRune && k.Rune == 'l':
+		s.enter()
+	case k.Code == infrastructure.KeyLeft, k.Code == infrastructure.KeyBackspace, k.Code == infrastructure.KeyEscape,
+		k.Code == infrastructure.KeyRune && k.Rune == 'h':
+		s.back()
+	case k.Code == infrastructure.KeyRune && k.Rune == '/':
+		s.searching, s.query = true, nil
+	case k.Code == infrastructure.KeyRune && k.Rune == 't':
+		s.showTypes = !s.showTypes
+	case k.Code == infrastructure.KeyRune && (k.Rune == 's' || k.Rune == 'n' || k.Rune == 'c'):
+		if !s.results {
+			s.sortBy = map[rune]string{'s': service.BrowseBySize, 'n': service.BrowseByName, 'c': service.BrowseByCount}[k.Rune]
+			s.open(s.dir, s.selected())
+		}
+	}
+}
+
+func (s *tuiState) handleSearchInput(k infrastructure.Key) {
+	switch k.Code {
+	case infrastructure.KeyEscape, infrastructure.KeyCtrlC:
+		s.searching = false
+	case infrastructure.KeyBackspace:
+		if len(s.query) > 0 {
+			s.query = s.query[:len(s.query)-1]
+		}
+	case infrastructure.KeyEnter:
+		s.searching = false
+		s.search(string(s.query))
+	case infrastructure.KeyRune:
+		s.query = append(s.query, k.Rune)
+	}
+}
+
+// search — нечёткий поиск по имени в текущей директории
+func (s *tuiState) search(q string) {
+	if strings.TrimSpace(q) == "" {
+		return
+	}
+	params := service.SearchParams{
+		Query:     q,
+		Match:     service.MatchOptions{Mode: service.MatchFuzzy},
+		Recursive: true,
+		Limit:     tuiSearchResults,
+	}
+	res, err := service.SearchIn(service.TreeSource(s.dir), params)
+	if err != nil {
+		s.status = "❌ " + err.Error()
+		return
+	}
+	var items []*model.FileInfo
+	for _, r := range res.Results {
+		if n := s.byPath[r.FullPathOrig]; n != nil && n != s.dir {
+			items = append(items, n)
+		}
+	}
+	if len(items) == 0 {
+		s.status = fmt.Sprintf("🔍 «%s»: ничего не найдено", q)
+		return
+	}
+	s.items, s.results, s.cursor, s.top = items, true, 0, 0
+	s.status = fmt.Sprintf("🔍 «%s»: найдено %d", q, res.Total)
+}
+
+func (s *tuiState) selected() *model.FileInfo {
+	if s.cursor < len(s.items) {
+		return s.items[s.cursor]
+	}
+	return nil
+}
+
+func (s *tuiState) move(delta int) {
+	s.cursor = min(max(s.cursor+delta, 0), max(len(s.items)-1, 0))
+}
+
+// enter — в директорию; из результатов поиска — к найденному элементу
+func (s *tuiState) enter() {
+	n := s.selected()
+	switch {
+	case n == nil:
+	case s.results && !n.IsDir:
+		s.open(s.parents[n], n)
+	case n.IsDir:
+		s.open(n, nil)
+	}
+}
+
+// back — из результатов поиска в директорию, иначе в родительскую
+func (s *tuiState) back() {
+	if s.results {
+		s.open(s.dir, nil)
+		return
+	}
+	if parent := s.parents[s.dir]; parent != nil {
+		s.open(parent, s.dir)
+	}
+}
+
+func (s *tuiState) typeBreakdown(dir *model.FileInfo) []service.TypeShare {
+	t, ok := s.types[dir]
+	if !ok {
+		t = service.TypeBreakdown(dir)
+		s.types[dir] = t
+	}
+	return t
+}
+
+// render — кадр целиком; строки обрезаются по ширине терминала
+func (s *tuiState) render() string {
+	var b strings.Builder
+	line := func(style, text string) {
+		b.WriteString(style + fitWidth(text, s.cols) + ansiReset + ansiClearLine + "\r\n")
+	}
+	b.WriteString(ansiHome)
+
+	title := fmt.Sprintf(" fsjson — %s  %s  (%d эл.)", s.dir.FullPathOrig, service.HumanSize(s.dir.SizeBytes), len(s.dir.Children))
+	if s.results {
+		title = fmt.Sprintf(" fsjson — поиск в %s", s.dir.FullPathOrig)
+	}
+	line(ansiReverse, padTo(title, s.cols))
+	line(ansiDim, fmt.Sprintf(" %10s  %-*s  %s", "Размер", tuiBarWidth+2, "Доля", "Имя (сортировка: "+s.sortBy+")"))
+
+	rows := s.listRows()
+	if s.cursor < s.top {
+		s.top = s.cursor
+	}
+	if s.cursor >= s.top+rows {
+		s.top = s.cursor - rows + 1
+	}
+	var largest int64 = 1
+	for _, n := range s.items {
+		largest = max(largest, n.SizeBytes)
+	}
+	for i := s.top; i < s.top+rows; i++ {
+		if i >= len(s.items) {
+			if i == 0 {
+				line("", "   (пусто)")
+			} else {
+				line("", "")
+			}
+			continue
+		}
+		n := s.items[i]
+		name := n.FullName
+		if s.results {
+			name = n.FullPathOrig
+		}
+		if n.IsDir {
+			name += "/"
+		}
+		text := fmt.Sprintf(" %10s  [%s]  %s", service.HumanSize(n.SizeBytes), sizeBar(n.SizeBytes, largest), name)
+		style := ""
+		if n.IsDir {
+			style = ansiBold
+		}
+		if i == s.cursor {
+			style, text = ansiReverse, padTo(text, s.cols)
+		}
+		line(style, text)
+	}
+
+	if s.showTypes {
+		dir := s.dir
+		if n := s.selected(); n != nil && n.IsDir {
+			dir = n
+		}
+		line(ansiReverse, padTo(" Типы файлов: "+dir.FullPathOrig, s.cols))
+		types := s.typeBreakdown(dir)
+		for i := range tuiTypeRows {
+			if i >= len(types) {
+				line("", "")
+				continue
+			}
+			t := types[i]
+			name := t.Type
+			if name == "" {
+				name = "—"
+			}
+			share := 0.0
+			if dir.SizeBytes > 0 {
+				share = float64(t.Bytes) / float64(dir.SizeBytes) * 100
+			}
+			line("", fmt.Sprintf(" %10s  [%s] %5.1f%%  %-10s %d файлов", service.HumanSize(t.Bytes),
+				sizeBar(t.Bytes, max(dir.SizeBytes, 1)), share, name, t.Files))
+		}
+	}
+
+	switch {
+	case s.searching:
+		b.WriteString(fitWidth(" / "+string(s.query), s.cols-1) + "█" + ansiClearLine)
+	case s.status != "":
+		b.WriteString(fitWidth(" "+s.status, s.cols) + ansiClearLine)
+	default:
+		b.WriteString(ansiDim + fitWidth(" ↑↓ выбор  →/Enter открыть  ← назад  / поиск  t типы  s/n/c сортировка  q выход", s.cols) + ansiReset + ansiClearLine)
+	}
+	b.WriteString(ansiClearBelow)
+	return b.String()
+}
+
+// sizeBar — полоса доли размера, как в ncdu
+func sizeBar(size, largest int64) string {
+	filled := min(max(int(size*tuiBarWidth/largest), 0), tuiBarWidth)
+	return strings.Repeat("#", filled) + strings.Repeat(" ", tuiBarWidth-filled)
+}
+
+// fitWidth обрезает строку до width символов. Через неё проходит весь текст,
+// выводимый в терминал, поэтому управляющие символы заменяются здесь же.
+func fitWidth(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	s = termSafe(s)
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:width-1]) + "…"
+}
+
+// termSafe заменяет управляющие символы (C0, C1, DEL) на '?': имя файла с ESC
+// или CSI/OSC-последовательностью иначе управляло бы терминалом — меняло заголовок,
+// писало в буфер обмена (OSC 52) или перерисовывало экран. Один символ заменяется
+// одним, так что ширина строки не меняется.
+func termSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// padTo дополняет строку пробелами до width символов (для строк с фоном)
+func padTo(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
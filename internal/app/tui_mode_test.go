@@ -0,0 +1,38 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func TestFitWidth_EscapesControlRunes(t *testing.T) {
+	// \u009b — CSI из C1; одиночный байт \x9b — не UTF-8 и становится U+FFFD
+	got := fitWidth("a\x1b[2Jb\u009bc\x07\x7fd\n\x9b", 80)
+	if want := "a?[2Jb?c??d?\uFFFD"; got != want {
+		t.Errorf("fitWidth = %q, want %q", got, want)
+	}
+}
+
+func TestRender_MaliciousNameNotInjected(t *testing.T) {
+	evil := "evil\x1b]52;c;ZXZpbA==\x07.txt"
+	root := model.FileInfo{
+		FullName: "root", FullPathOrig: "/root", IsDir: true, SizeBytes: 10,
+		Children: []model.FileInfo{
+			{FullName: evil, FullPathOrig: "/root/" + evil, SizeBytes: 10},
+		},
+	}
+	s := newTUIState(&root)
+	s.showTypes = true
+
+	out := s.render()
+	for _, bad := range []string{"\x1b]52", "\x07"} {
+		if strings.Contains(out, bad) {
+			t.Errorf("render содержит %q: %q", bad, out)
+		}
+	}
+	if !strings.Contains(out, "evil?]52;c;ZXZpbA==?.txt") {
+		t.Errorf("имя не выведено в экранированном виде: %q", out)
+	}
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"fsjson/internal/infrastructure"
+)
+
+// maxPrintedIssues — сколько нарушений показывать в подсказках merge-режима
+const maxPrintedIssues = 5
+
+// ValidateMode проверяет структуру и инварианты JSON-файла снимка.
+// Возвращает false, если найдены нарушения или файл не удалось прочитать.
+func ValidateMode(path string) bool {
+	fmt.Printf("🔎 Проверка %s...\n", path)
+	shape, issues, err := infrastructure.ValidateJSONFile(path)
+	if err != nil {
+		fmt.Printf("❌ Ошибка чтения %s: %v\n", path, err)
+		return false
+	}
+	for _, is := range issues {
+		fmt.Printf("❌ %s\n", is)
+	}
+	if len(issues) > 0 {
+		fmt.Printf("⚠️ Найдено нарушений: %d\n", len(issues))
+		return false
+	}
+	fmt.Printf("✅ Файл корректен (%s)\n", shape)
+	return true
+}
+
+// explainInvalid печатает первые нарушения схемы, чтобы было понятно,
+// почему файл не распознан
+func explainInvalid(path string) {
+	_, issues, err := infrastructure.ValidateJSONFile(path)
+	if err != nil {
+		return
+	}
+	for i, is := range issues {
+		if i == maxPrintedIssues {
+			fmt.Printf("   … и ещё %d (подробно: --validate %s)\n", len(issues)-i, path)
+			break
+		}
+		fmt.Printf("   %s\n", is)
+	}
+}
+
+// PrintSchema выводит JSON Schema форматов снимка
+func PrintSchema() {
+	_, _ = os.Stdout.Write(infrastructure.MarshalJSONSchema())
+}
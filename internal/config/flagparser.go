@@ -70,3 +70,14 @@ func ParseFlagsSafe() {
 	os.Args = validArgs
 	flag.Parse()
 }
+
+// FlagPassed — был ли флаг явно указан в командной строке
+func FlagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
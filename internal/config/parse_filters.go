@@ -2,11 +2,12 @@ package config
 
 import (
 	"flag"
-	"net/url"
+	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"time"
+
+	"fsjson/internal/domain/service"
 )
 
 // ParseTypes разбивает строку по запятым
@@ -21,56 +22,73 @@ func ParseTypes(s string) []string {
 	return parts
 }
 
-// ParseSizeFilters поддерживает gt, lt, eq, between
-func ParseSizeFilters(m url.Values) map[string]int64 {
+// SizeOps и TimeOps — операции фильтров size.<op>, depth.<op>, created.<op>, modified.<op>
+var (
+	SizeOps = []string{"gt", "gte", "lt", "lte", "eq"}
+	TimeOps = []string{"gt", "gte", "lt", "lte"}
+)
+
+// sizeFilters — prefix.gt, gte, lt, lte, eq и between=min,max; parse разбирает одно значение
+func sizeFilters(get func(key string) string, prefix string, parse func(string) (int64, error)) (map[string]int64, error) {
 	out := make(map[string]int64)
-	for _, op := range []string{"gt", "gte", "lt", "lte", "eq"} {
-		if v := m.Get("size." + op); v != "" {
-			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
-				out[op] = n
+	for _, op := range SizeOps {
+		key := prefix + "." + op
+		if v := get(key); v != "" {
+			n, err := parse(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
 			}
+			out[op] = n
 		}
 	}
-	if v := m.Get("size.between"); v != "" {
-		parts := strings.Split(v, ",")
-		if len(parts) == 2 {
-			min, _ := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
-			max, _ := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
-			out["between"] = 1
-			out["between_min"] = min
-			out["between_max"] = max
+	if v := get(prefix + ".between"); v != "" {
+		lo, hi, ok := strings.Cut(v, ",")
+		if !ok {
+			return nil, fmt.Errorf("%s.between: ожидалось min,max, получено %q", prefix, v)
 		}
-	}
-	return out
-}
-
-// ParseTimeFilters поддерживает gt, gte, lt, lte
-func ParseTimeFilters_(m url.Values, prefix string) map[string]time.Time {
-	out := make(map[string]time.Time)
-	for _, op := range []string{"gt", "gte", "lt", "lte"} {
-		key := prefix + "." + op
-		if v := m.Get(key); v != "" {
-			if t, err := ParseISOTime(v); err == nil {
-				out[op] = t
-			}
+		min, err := parse(lo)
+		if err != nil {
+			return nil, fmt.Errorf("%s.between: %w", prefix, err)
+		}
+		max, err := parse(hi)
+		if err != nil {
+			return nil, fmt.Errorf("%s.between: %w", prefix, err)
 		}
+		out["between"] = 1
+		out["between_min"] = min
+		out["between_max"] = max
 	}
-	return out
+	return out, nil
 }
 
-func ParseTimeFilters(prefix string) map[string]time.Time {
+// timeFilters читает prefix и prefix.gt, gte, lt, lte.
+// Значение — дата, смещение (-7d, 2y) или период (today, last-month), см. service.ParseTimeExpr;
+// само prefix (--modified=last-month) — попадание в период (для момента — не раньше него).
+func timeFilters(get func(key string) string, prefix string, now time.Time, loc *time.Location) (map[string]time.Time, error) {
 	m := make(map[string]time.Time)
-	for _, op := range []string{"gt", "gte", "lt", "lte"} {
+	if v := get(prefix); v != "" {
+		from, to, err := service.ParseTimeExpr(v, now, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+		m["gte"] = from
+		if !to.IsZero() {
+			m["lt"] = to
+		}
+	}
+	for _, op := range TimeOps {
 		key := prefix + "." + op
-		val := GetEnvOrFlag(key)
+		val := get(key)
 		if val == "" {
 			continue
 		}
-		if t, err := ParseISOTime(val); err == nil {
-			m[op] = t
+		from, to, err := service.ParseTimeExpr(val, now, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
 		}
+		m[op] = service.TimeBound(op, from, to)
 	}
-	return m
+	return m, nil
 }
 
 // GetEnvOrFlag возвращает значение из:
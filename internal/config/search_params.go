@@ -0,0 +1,164 @@
+package config
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fsjson/internal/domain/service"
+)
+
+// Параметры поиска одинаковы в CLI (--size.gt=1GB) и в /api/search (size.gt=1GB):
+//
+//	query, mode, case-sensitive, match-path, path, recursive — что искать и как сравнивать;
+//	type, ext, size.*, depth.*, created[.*], modified[.*], tz — фильтры по свойствам;
+//	perm, owner, hashed, only — права, владелец, наличие хеша, только директории или файлы;
+//	md5, sha1, sha256, sha512, like — то же содержимое: по значению хеша или как у данного файла;
+//	content — слова в содержимом (по индексу содержимого);
+//	sort, order, limit, offset, cursor — порядок и страницы.
+//
+// ParseSearchParams получает значения через get, поэтому один разбор обслуживает оба интерфейса.
+
+// RegisterSearchFlags регистрирует флаги --search; path, type, ext, sort, limit и offset
+// общие с --find-duplicates и регистрируются вместе с основными флагами
+// (ParseFlagsSafe пропускает незарегистрированные флаги)
+func RegisterSearchFlags() {
+	flag.String("query", "", "Запрос поиска: шаблон имени или выражение (ext:(mp4|mkv) AND size>1GB AND NOT path:/tmp/)")
+	flag.String("mode", "", "Режим шаблонов поиска: wildcard (по умолчанию), literal, regex, glob или fuzzy (нечёткий, с ранжированием)")
+	flag.Bool("case-sensitive", false, "Поиск с учётом регистра")
+	flag.Bool("match-path", false, "Сопоставлять шаблон с полным путём, а не с именем")
+	flag.String("order", "", "Порядок сортировки результатов поиска: asc или desc")
+	flag.String("cursor", "", "Курсор следующей страницы результатов поиска")
+	flag.String("tz", "", "Часовой пояс для дат без зоны и периодов вроде today (Europe/Moscow, UTC); по умолчанию местный")
+	for p, hint := range map[string]string{"size": "байты или 100MB, 1.5GB", "depth": "уровней от корня"} {
+		for _, op := range SizeOps {
+			flag.String(p+"."+op, "", fmt.Sprintf("Фильтр %s %s: %s", p, op, hint))
+		}
+		flag.String(p+".between", "", fmt.Sprintf("Фильтр %s: диапазон min,max включительно", p))
+	}
+	for _, p := range []string{"created", "modified"} {
+		flag.String(p, "", fmt.Sprintf("Фильтр %s: период (today, last-month, 2025-01) или момент (-7d)", p))
+		for _, op := range TimeOps {
+			flag.String(p+"."+op, "", fmt.Sprintf("Фильтр %s %s: дата, смещение (-7d, 2y) или период", p, op))
+		}
+	}
+	flag.String("perm", "", "Права: 755, 0644 или шаблон строки прав (-rw-r--r--, d*)")
+	flag.String("owner", "", "Владелец через запятую: user, :group или user:group")
+	flag.String("hashed", "", "Только файлы с хешем: md5, sha256, …, any; none — без хеша")
+	flag.String("only", "", "Только директории (dir) или только файлы (file)")
+	for _, a := range service.HashAlgorithms {
+		flag.String(a, "", fmt.Sprintf("Файлы с данным хешем %s", a))
+	}
+	flag.String("content", "", "Слова в содержимом файлов (нужен индекс --content-index): все в одной строке, conf* — префикс; у слова учитываются первые 100 строк файла")
+	flag.String("like", "", "Файлы с тем же содержимым, что и данный (путь в снимке или на диске; по HTTP — только в снимке)")
+}
+
+// FlagValue — значение флага CLI (или его значение по умолчанию);
+// для незаданного флага — переменная окружения FSJSON_<ИМЯ> (size.gt → FSJSON_SIZE_GT)
+func FlagValue(name string) string {
+	f := flag.Lookup(name)
+	if f != nil && FlagPassed(name) {
+		return strings.TrimSpace(f.Value.String())
+	}
+	env := "FSJSON_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+	if v, ok := os.LookupEnv(env); ok {
+		return strings.TrimSpace(v)
+	}
+	if f != nil {
+		return f.DefValue
+	}
+	return ""
+}
+
+// QueryValue — значение параметра запроса; case-sensitive можно передать и как case_sensitive
+func QueryValue(q url.Values) func(key string) string {
+	return func(key string) string {
+		if v := q.Get(key); v != "" {
+			return strings.TrimSpace(v)
+		}
+		return strings.TrimSpace(q.Get(strings.ReplaceAll(key, "-", "_")))
+	}
+}
+
+// ParseSearchParams разбирает параметры поиска; ошибка называет параметр с неверным значением
+func ParseSearchParams(get func(key string) string, now time.Time) (service.SearchParams, error) {
+	var p service.SearchParams
+	var err error
+
+	if p.Match.Mode, err = service.ParseMatchMode(get("mode")); err != nil {
+		return p, err
+	}
+	if p.Sort, p.Desc, err = service.ParseSearchSort(get("sort"), get("order")); err != nil {
+		return p, err
+	}
+	if p.Location, err = ParseLocation(get("tz")); err != nil {
+		return p, fmt.Errorf("некорректный часовой пояс: %w", err)
+	}
+	if p.Limit, err = intParam(get, "limit", 100); err != nil {
+		return p, err
+	}
+	if p.Offset, err = intParam(get, "offset", 0); err != nil {
+		return p, err
+	}
+	if p.SizeCmp, err = sizeFilters(get, "size", service.ParseSize); err != nil {
+		return p, err
+	}
+	if p.DepthCmp, err = sizeFilters(get, "depth", parseDepth); err != nil {
+		return p, err
+	}
+	if p.Created, err = timeFilters(get, "created", now, p.Location); err != nil {
+		return p, err
+	}
+	if p.Modified, err = timeFilters(get, "modified", now, p.Location); err != nil {
+		return p, err
+	}
+
+	p.Query = get("query")
+	p.Match.CaseSensitive = parseBool(get("case-sensitive"))
+	p.Match.FullPath = parseBool(get("match-path"))
+	p.Path = get("path")
+	p.Recursive = get("recursive") == "" || parseBool(get("recursive"))
+	p.Cursor = get("cursor")
+	p.Types = ParseTypes(cmp.Or(get("type"), get("types")))
+	p.Exts = ParseTypes(get("ext"))
+	p.Perm = get("perm")
+	p.Owners = ParseTypes(get("owner"))
+	p.Hashed = strings.ToLower(get("hashed"))
+	p.Only = strings.ToLower(get("only"))
+	p.Like = get("like")
+	p.Content = get("content")
+	for _, a := range service.HashAlgorithms {
+		if v := get(a); v != "" {
+			if p.Hashes == nil {
+				p.Hashes = make(map[string]string)
+			}
+			p.Hashes[a] = v
+		}
+	}
+	return p, nil
+}
+
+func intParam(get func(key string) string, key string, def int) (int, error) {
+	v := get(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s: ожидалось неотрицательное число, получено %q", key, v)
+	}
+	return n, nil
+}
+
+func parseDepth(s string) (int64, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("некорректная глубина %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"flag"
+	"time"
+
+	"fsjson/internal/domain/service"
+)
+
+// RegisterStatsFlags регистрирует флаги --stats; фильтры — общие с поиском (RegisterSearchFlags),
+// sort, order и limit тоже общие
+func RegisterStatsFlags() {
+	flag.String("group-by", "", "Группировка статистики через запятую: type, ext, dir:N, owner, year, month, size")
+}
+
+// ParseStatsParams разбирает параметры статистики (--stats и /api/stats): group-by, sort
+// (sum, count, avg, min, max, key), order, limit — число групп; остальное — фильтры поиска
+func ParseStatsParams(get func(key string) string, now time.Time) (service.StatsParams, error) {
+	var p service.StatsParams
+	var err error
+	filters := func(key string) string {
+		switch key {
+		case "sort", "order", "cursor", "offset":
+			return ""
+		}
+		return get(key)
+	}
+	if p.Search, err = ParseSearchParams(filters, now); err != nil {
+		return p, err
+	}
+	if p.GroupBy, err = service.ParseStatsGroupBy(get("group-by")); err != nil {
+		return p, err
+	}
+	if p.Sort, p.Desc, err = service.ParseStatsSort(get("sort"), get("order")); err != nil {
+		return p, err
+	}
+	p.Limit = p.Search.Limit
+	return p, nil
+}
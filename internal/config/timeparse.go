@@ -1,9 +1,9 @@
 package config
 
 import (
-	"errors"
-	"strings"
 	"time"
+
+	"fsjson/internal/domain/service"
 )
 
 // ParseISOTime поддерживает форматы:
@@ -12,21 +12,23 @@ import (
 //	2025-11-08T10:00
 //	2025-11-08T10:00:00
 //	2025-11-08T10:00:00Z
+//	2025-11-08T10:00:00+03:00
+//
+// Время без зоны понимается как UTC; для другого пояса — ParseISOTimeIn.
 func ParseISOTime(s string) (time.Time, error) {
-	layouts := []string{
-		time.RFC3339,
-		"2006-01-02T15:04:05",
-		"2006-01-02T15:04",
-		"2006-01-02",
-	}
-	s = strings.TrimSpace(s)
-	var err error
-	for _, layout := range layouts {
-		if t, err := time.Parse(layout, s); err == nil {
-			return t, nil
-		} else {
-			err = errors.Join(err)
-		}
+	return ParseISOTimeIn(s, time.UTC)
+}
+
+// ParseISOTimeIn — ParseISOTime, где время без зоны понимается в loc
+func ParseISOTimeIn(s string, loc *time.Location) (time.Time, error) {
+	t, _, err := service.ParseDate(s, loc)
+	return t, err
+}
+
+// ParseLocation — часовой пояс по имени IANA (Europe/Moscow), UTC или Local; "" — местный
+func ParseLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
 	}
-	return time.Time{}, err
+	return time.LoadLocation(name)
 }
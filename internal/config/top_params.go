@@ -0,0 +1,32 @@
+package config
+
+import (
+	"flag"
+	"time"
+
+	"fsjson/internal/domain/service"
+)
+
+// RegisterTopFlags регистрирует флаги --top; фильтры — общие с поиском (RegisterSearchFlags)
+func RegisterTopFlags() {
+	flag.Int("top-n", service.DefaultTopN, "Сколько элементов в каждом списке --top")
+}
+
+// ParseTopParams разбирает параметры --top и /api/top: top-n (в API и top_n) — длина списков,
+// остальное — фильтры поиска
+func ParseTopParams(get func(key string) string, now time.Time) (service.TopParams, error) {
+	var p service.TopParams
+	var err error
+	filters := func(key string) string {
+		switch key {
+		case "sort", "order", "cursor", "offset", "limit":
+			return ""
+		}
+		return get(key)
+	}
+	if p.Search, err = ParseSearchParams(filters, now); err != nil {
+		return p, err
+	}
+	p.N, err = intParam(get, "top-n", service.DefaultTopN)
+	return p, err
+}
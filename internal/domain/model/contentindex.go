@@ -0,0 +1,23 @@
+package model
+
+// ContentIndexSchema — значение поля Schema файла индекса содержимого
+const ContentIndexSchema = "fsjson.content-index"
+
+// ContentIndexVersion — текущая версия формата индекса
+const ContentIndexVersion = 1
+
+// ContentIndex — обратный индекс содержимого текстовых файлов снимка.
+// Лежит рядом со снимком (structure.json → structure.content.json).
+//
+// Terms: слово (в нижнем регистре) → список вхождений [номер файла в Files, строка, строка, …];
+// строки нумеруются с единицы, на файл хранится не больше ContentIndexMaxLines строк слова.
+type ContentIndex struct {
+	Schema      string             `json:"Schema"`
+	Version     int                `json:"Version"`
+	MaxFileSize int64              `json:"MaxFileSize"` // файлы больше не индексировались
+	Files       []string           `json:"Files"`       // FullPathOrig проиндексированных файлов
+	Terms       map[string][][]int `json:"Terms"`
+}
+
+// ContentIndexMaxLines — сколько строк одного слова в файле запоминается
+const ContentIndexMaxLines = 100
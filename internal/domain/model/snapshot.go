@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// SnapshotSchema — значение поля Schema, по которому конверт отличается от голого FileInfo
+const SnapshotSchema = "fsjson.snapshot"
+
+// SnapshotVersion — текущая версия формата конверта
+const SnapshotVersion = 1
+
+// DirHashMerkle — хеши директорий посчитаны по содержимому (см. service.ComputeMerkleHashes)
+const DirHashMerkle = "merkle"
+
+// SnapshotOptions — параметры, с которыми выполнялось сканирование
+type SnapshotOptions struct {
+	Workers        int      `json:"Workers"`
+	IOLimit        int      `json:"IOLimit"`
+	Excludes       []string `json:"Excludes"`
+	HashAlgorithms []string `json:"HashAlgorithms"`
+	Stream         bool     `json:"Stream"`
+	DirHash        string   `json:"DirHash,omitempty"`      // "" — у директорий нет хеша
+	ImageHash      string   `json:"ImageHash,omitempty"`    // алгоритм перцептивного хеша изображений
+	ContentIndex   string   `json:"ContentIndex,omitempty"` // имя файла индекса содержимого рядом со снимком
+}
+
+// SnapshotCounts — количество элементов в снимке
+type SnapshotCounts struct {
+	Files int64 `json:"Files"`
+	Dirs  int64 `json:"Dirs"`
+	Bytes int64 `json:"Bytes"`
+}
+
+// SnapshotErrors — ошибки, пропущенные при сканировании
+type SnapshotErrors struct {
+	Total int64 `json:"Total"`
+	Walk  int64 `json:"Walk"`
+	Stat  int64 `json:"Stat"`
+	Hash  int64 `json:"Hash"`
+}
+
+// Snapshot — конверт снимка: метаданные сканирования и само дерево (или flat-массив).
+// Schema всегда записывается первым полем, Tree/Items — последними,
+// чтобы метаданные можно было прочитать, не разбирая дерево.
+type Snapshot struct {
+	Schema       string          `json:"Schema"`
+	Version      int             `json:"Version"`
+	ToolVersion  string          `json:"ToolVersion"`
+	Hostname     string          `json:"Hostname"`
+	RootPath     string          `json:"RootPath"`
+	ScanStarted  time.Time       `json:"ScanStarted"`
+	ScanFinished time.Time       `json:"ScanFinished"`
+	Options      SnapshotOptions `json:"Options"`
+	Counts       SnapshotCounts  `json:"Counts"`
+	Errors       SnapshotErrors  `json:"Errors"`
+	Tree         *FileInfo       `json:"Tree,omitempty"`
+	Items        []FileInfo      `json:"Items,omitempty"`
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"fsjson/internal/domain/model"
+)
+
+// Сортировка элементов директории при просмотре (--tui)
+const (
+	BrowseBySize  = "size" // по размеру, большие сверху (как в ncdu)
+	BrowseByName  = "name"
+	BrowseByCount = "count" // по числу элементов внутри
+)
+
+// BrowseChildren — элементы директории в порядке просмотра; при равенстве — по имени
+func BrowseChildren(dir *model.FileInfo, by string) []*model.FileInfo {
+	out := make([]*model.FileInfo, len(dir.Children))
+	for i := range dir.Children {
+		out[i] = &dir.Children[i]
+	}
+	byName := func(a, b *model.FileInfo) int {
+		return cmp.Or(strings.Compare(strings.ToLower(a.FullName), strings.ToLower(b.FullName)), strings.Compare(a.FullName, b.FullName))
+	}
+	slices.SortFunc(out, func(a, b *model.FileInfo) int {
+		switch by {
+		case BrowseByName:
+			return byName(a, b)
+		case BrowseByCount:
+			return cmp.Or(cmp.Compare(len(b.Children), len(a.Children)), byName(a, b))
+		}
+		return cmp.Or(cmp.Compare(b.SizeBytes, a.SizeBytes), byName(a, b))
+	})
+	return out
+}
+
+// TypeShare — сколько файлов одного типа и сколько они занимают
+type TypeShare struct {
+	Type  string `json:"type"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// TypeBreakdown — файлы директории (со всеми вложенными) по FileType, большие сверху
+func TypeBreakdown(dir *model.FileInfo) []TypeShare {
+	byType := make(map[string]*TypeShare)
+	var walk func(n *model.FileInfo)
+	walk = func(n *model.FileInfo) {
+		if !n.IsDir {
+			s := byType[n.FileType]
+			if s == nil {
+				s = &TypeShare{Type: n.FileType}
+				byType[n.FileType] = s
+			}
+			s.Files++
+			s.Bytes += n.SizeBytes
+			return
+		}
+		for i := range n.Children {
+			walk(&n.Children[i])
+		}
+	}
+	walk(dir)
+
+	out := make([]TypeShare, 0, len(byType))
+	for _, s := range byType {
+		out = append(out, *s)
+	}
+	slices.SortFunc(out, func(a, b TypeShare) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(b.Files, a.Files), strings.Compare(a.Type, b.Type))
+	})
+	return out
+}
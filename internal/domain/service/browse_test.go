@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func TestBrowseChildren(t *testing.T) {
+	dir := model.FileInfo{IsDir: true, Children: []model.FileInfo{
+		{FullName: "b.txt", SizeBytes: 10},
+		{FullName: "A", IsDir: true, SizeBytes: 10, Children: make([]model.FileInfo, 3)},
+		{FullName: "c", IsDir: true, SizeBytes: 50, Children: make([]model.FileInfo, 1)},
+	}}
+	names := func(list []*model.FileInfo) string {
+		out := []string{}
+		for _, n := range list {
+			out = append(out, n.FullName)
+		}
+		return fmt.Sprint(out)
+	}
+	for by, want := range map[string]string{
+		BrowseBySize:  "[c A b.txt]", // равные размеры — по имени без учёта регистра
+		BrowseByName:  "[A b.txt c]",
+		BrowseByCount: "[A c b.txt]",
+	} {
+		if got := names(BrowseChildren(&dir, by)); got != want {
+			t.Errorf("%s: %s, ожидалось %s", by, got, want)
+		}
+	}
+	if BrowseChildren(&dir, BrowseBySize)[0] != &dir.Children[2] {
+		t.Error("должны возвращаться указатели на узлы дерева")
+	}
+}
+
+func TestTypeBreakdown(t *testing.T) {
+	root := statsTree()
+	got := fmt.Sprint(TypeBreakdown(&root))
+	if want := "[{video 3 8000} {text 1 10}]"; got != want {
+		t.Errorf("%s, ожидалось %s", got, want)
+	}
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"fsjson/internal/domain/model"
+)
+
+// DefaultContentMaxSize — по умолчанию индексируются файлы до 1 МБ
+const DefaultContentMaxSize = 1 << 20
+
+// CanIndexContent — индексируется ли содержимое файлов этого типа
+func CanIndexContent(fileType string) bool {
+	return fileType == "text" || fileType == "code"
+}
+
+// ContentIndexBuilder собирает индекс содержимого; безопасен для воркеров сканирования
+type ContentIndexBuilder struct {
+	maxSize int64
+	mu      sync.Mutex
+	files   []string
+	terms   map[string][][]int
+}
+
+// NewContentIndexBuilder — индексатор для файлов не больше maxSize байт
+func NewContentIndexBuilder(maxSize int64) *ContentIndexBuilder {
+	return &ContentIndexBuilder{maxSize: maxSize, terms: make(map[string][][]int)}
+}
+
+// MaxSize — предел размера индексируемого файла
+func (b *ContentIndexBuilder) MaxSize() int64 { return b.maxSize }
+
+// Add индексирует содержимое файла. Файл, который не является корректным UTF-8
+// (двоичный или в другой кодировке), пропускается — тогда возвращается false.
+func (b *ContentIndexBuilder) Add(path string, data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+	lines := make(map[string][]int)
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		for _, t := range contentTokens(string(line)) {
+			l := lines[t]
+			if len(l) < model.ContentIndexMaxLines && (len(l) == 0 || l[len(l)-1] != i+1) {
+				lines[t] = append(l, i+1)
+			}
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := len(b.files)
+	b.files = append(b.files, path)
+	for t, l := range lines {
+		b.terms[t] = append(b.terms[t], append([]int{id}, l...))
+	}
+	return true
+}
+
+// AddFile читает и индексирует файл; false — файл больше предела, не читается или не текст
+func (b *ContentIndexBuilder) AddFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, b.maxSize+1))
+	if err != nil || int64(len(data)) > b.maxSize {
+		return false
+	}
+	return b.Add(path, data)
+}
+
+// Build возвращает индекс: файлы по алфавиту, вхождения — в порядке файлов
+func (b *ContentIndexBuilder) Build() *model.ContentIndex {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	order := make([]int, len(b.files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return b.files[order[i]] < b.files[order[j]] })
+	remap := make([]int, len(order))
+	files := make([]string, len(order))
+	for newID, oldID := range order {
+		remap[oldID] = newID
+		files[newID] = b.files[oldID]
+	}
+	terms := make(map[string][][]int, len(b.terms))
+	for t, postings := range b.terms {
+		out := make([][]int, len(postings))
+		for i, p := range postings {
+			out[i] = append([]int{remap[p[0]]}, p[1:]...)
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+		terms[t] = out
+	}
+	return &model.ContentIndex{
+		Schema:      model.ContentIndexSchema,
+		Version:     model.ContentIndexVersion,
+		MaxFileSize: b.maxSize,
+		Files:       files,
+		Terms:       terms,
+	}
+}
+
+// contentTokens — слова строки: буквы, цифры и '_' любого алфавита, в нижнем регистре,
+// от 2 до 64 байт
+func contentTokens(s string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if utf8.RuneCountInString(w) >= 2 && len(w) <= 64 {
+			out = append(out, strings.ToLower(w))
+		}
+	}
+	return out
+}
+
+// ContentSearcher — поиск по загруженному индексу содержимого
+type ContentSearcher struct {
+	idx   *model.ContentIndex
+	words []string // слова по алфавиту, для поиска по префиксу
+}
+
+// NewContentSearcher проверяет индекс и готовит его к поиску
+func NewContentSearcher(idx *model.ContentIndex) (*ContentSearcher, error) {
+	if idx.Schema != model.ContentIndexSchema {
+		return nil, fmt.Errorf("это не индекс содержимого (Schema=%q)", idx.Schema)
+	}
+	if idx.Version > model.ContentIndexVersion {
+		return nil, fmt.Errorf("индекс содержимого версии %d новее поддерживаемой (%d)", idx.Version, model.ContentIndexVersion)
+	}
+	// индекс читается из файла рядом со снимком: номер файла вне Files уронил бы поиск
+	for t, postings := range idx.Terms {
+		for _, p := range postings {
+			if len(p) == 0 || p[0] < 0 || p[0] >= len(idx.Files) {
+				return nil, fmt.Errorf("индекс содержимого повреждён: слово %q ссылается на несуществующий файл", t)
+			}
+			// строки по возрастанию без повторов: на этом держится intersectSorted
+			prev := 0
+			for _, line := range p[1:] {
+				if line <= prev {
+					return nil, fmt.Errorf("индекс содержимого повреждён: слово %q, строка %d после %d", t, line, prev)
+				}
+				prev = line
+			}
+		}
+	}
+	words := make([]string, 0, len(idx.Terms))
+	for t := range idx.Terms {
+		words = append(words, t)
+	}
+	sort.Strings(words)
+	return &ContentSearcher{idx: idx, words: words}, nil
+}
+
+// Find ищет файлы, где все слова выражения встречаются в одной строке.
+// Слово со звёздочкой на конце (conf*) — поиск по префиксу. Для каждого слова в индексе
+// хранятся только первые ContentIndexMaxLines строк файла, поэтому совпадения
+// нескольких слов дальше этих строк не находятся.
+// Результат: FullPathOrig → номера подходящих строк.
+func (s *ContentSearcher) Find(expr string) (map[string][]int, error) {
+	type word struct {
+		text   string
+		prefix bool
+	}
+	var words []word
+	for _, f := range strings.Fields(expr) {
+		prefix := strings.HasSuffix(f, "*")
+		toks := contentTokens(strings.TrimRight(f, "*"))
+		for i, t := range toks {
+			words = append(words, word{t, prefix && i == len(toks)-1})
+		}
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("content: нет слов для поиска в %q (слова — от двух букв или цифр)", expr)
+	}
+
+	var lines map[int][]int // файл → строки, где есть все слова
+	for i, w := range words {
+		found := s.lookup(w.text, w.prefix)
+		if i == 0 {
+			lines = found
+			continue
+		}
+		for f, l := range lines {
+			if common := intersectSorted(l, found[f]); len(common) > 0 {
+				lines[f] = common
+			} else {
+				delete(lines, f)
+			}
+		}
+	}
+	out := make(map[string][]int, len(lines))
+	for f, l := range lines {
+		out[s.idx.Files[f]] = l
+	}
+	return out, nil
+}
+
+// lookup — вхождения слова (или всех слов с префиксом): файл → отсортированные строки
+func (s *ContentSearcher) lookup(w string, prefix bool) map[int][]int {
+	out := make(map[int][]int)
+	add := func(t string) {
+		for _, p := range s.idx.Terms[t] {
+			if len(p) > 1 {
+				out[p[0]] = append(out[p[0]], p[1:]...)
+			}
+		}
+	}
+	if !prefix {
+		add(w)
+	} else {
+		for i := sort.SearchStrings(s.words, w); i < len(s.words) && strings.HasPrefix(s.words[i], w); i++ {
+			add(s.words[i])
+		}
+	}
+	// строки одного файла могут прийти из нескольких записей (разные слова префикса
+	// или повтор файла у слова) — intersectSorted нужен отсортированный список без повторов
+	for f, l := range out {
+		slices.Sort(l)
+		out[f] = slices.Compact(l)
+	}
+	return out
+}
+
+func intersectSorted(a, b []int) []int {
+	var out []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// ContentSnippet — строка файла, где нашлось содержимое
+type ContentSnippet struct {
+	Line int    `json:"Line"`
+	Text string `json:"Text,omitempty"` // пусто, если файл сейчас недоступен
+}
+
+// Сколько строк показывать на файл и какой длины
+const (
+	maxSnippets    = 3
+	maxSnippetText = 200
+)
+
+// readSnippets читает строки файла с диска; если файла нет, остаются только номера строк
+func readSnippets(path string, lines []int) []ContentSnippet {
+	if len(lines) > maxSnippets {
+		lines = lines[:maxSnippets]
+	}
+	out := make([]ContentSnippet, len(lines))
+	for i, l := range lines {
+		out[i].Line = l
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return out
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), DefaultContentMaxSize)
+	next := 0
+	for n := 1; sc.Scan() && next < len(out); n++ {
+		if n == out[next].Line {
+			out[next].Text = snippetText(sc.Text())
+			next++
+		}
+	}
+	return out
+}
+
+func snippetText(s string) string {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\t", " "))
+	if utf8.RuneCountInString(s) <= maxSnippetText {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:maxSnippetText]) + "…"
+}
+
+// contentCond — условие на содержимое (content: в запросе или параметр content);
+// hits заполняется из индекса перед обходом снимка
+type contentCond struct {
+	expr string
+	hits map[string][]int
+}
+
+func (c *contentCond) match(n *model.FileInfo) bool {
+	_, ok := c.hits[n.FullPathOrig]
+	return ok && !n.IsDir
+}
+
+// bindContent находит в индексе файлы для условий на содержимое
+func bindContent(conds []*contentCond, load func() (*ContentSearcher, error)) error {
+	if len(conds) == 0 {
+		return nil
+	}
+	if load == nil {
+		return fmt.Errorf("поиск по содержимому требует индекса: сканируйте с --content-index")
+	}
+	s, err := load()
+	if err != nil {
+		return err
+	}
+	for _, c := range conds {
+		if c.hits, err = s.Find(c.expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachSnippets добавляет к результатам строки, где нашлось содержимое
+func attachSnippets(results []SearchResult, conds []*contentCond) {
+	for i := range results {
+		var lines []int
+		for _, c := range conds {
+			lines = append(lines, c.hits[results[i].FullPathOrig]...)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		slices.Sort(lines)
+		results[i].Snippets = readSnippets(results[i].FullPathOrig, slices.Compact(lines))
+	}
+}
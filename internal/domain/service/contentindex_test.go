@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func TestContentTokens(t *testing.T) {
+	got := fmt.Sprint(contentTokens("Open_File(path) — Открыть файл, x = 42; a"))
+	if got != "[open_file path открыть файл 42]" {
+		t.Errorf("слова: %s", got)
+	}
+}
+
+func TestContentIndexBuilder(t *testing.T) {
+	b := NewContentIndexBuilder(1 << 10)
+	if !b.Add("/b.txt", []byte("\xef\xbb\xbfhello world\nsecond line: Hello again")) {
+		t.Fatal("текст должен индексироваться")
+	}
+	b.Add("/a.go", []byte("package hello\n"))
+	if b.Add("/bin", []byte("ELF\x00\x01")) || b.Add("/cp1251", []byte("\xcf\xf0\xe8\xe2\xe5\xf2")) {
+		t.Error("двоичные и не-UTF-8 файлы должны пропускаться")
+	}
+
+	idx := b.Build()
+	if fmt.Sprint(idx.Files) != "[/a.go /b.txt]" {
+		t.Errorf("файлы должны идти по алфавиту: %v", idx.Files)
+	}
+	if got := fmt.Sprint(idx.Terms["hello"]); got != "[[0 1] [1 1 2]]" {
+		t.Errorf("вхождения hello: %s", got)
+	}
+}
+
+func testContentSearcher(t *testing.T, files map[string]string) *ContentSearcher {
+	t.Helper()
+	b := NewContentIndexBuilder(1 << 10)
+	for p, text := range files {
+		b.Add(p, []byte(text))
+	}
+	s, err := NewContentSearcher(b.Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestContentSearcher_Find(t *testing.T) {
+	s := testContentSearcher(t, map[string]string{
+		"/a.txt": "open the file\nclose file",
+		"/b.txt": "open\nfile",
+		"/c.txt": "configuration and config",
+	})
+	cases := map[string]string{
+		"file":           "map[/a.txt:[1 2] /b.txt:[2]]",
+		"open file":      "map[/a.txt:[1]]", // слова в одной строке
+		"FILE.open":      "map[/a.txt:[1]]",
+		"conf*":          "map[/c.txt:[1]]",
+		"config":         "map[/c.txt:[1]]",
+		"configurations": "map[]",
+	}
+	for expr, want := range cases {
+		got, err := s.Find(expr)
+		if err != nil {
+			t.Errorf("%q: %v", expr, err)
+			continue
+		}
+		if fmt.Sprint(got) != want {
+			t.Errorf("%q: %v, ожидалось %s", expr, got, want)
+		}
+	}
+	if _, err := s.Find("a !"); err == nil {
+		t.Error("выражение без слов должно быть ошибкой")
+	}
+	if _, err := NewContentSearcher(&model.ContentIndex{Schema: "other"}); err == nil {
+		t.Error("чужой файл не должен приниматься за индекс")
+	}
+	for name, terms := range map[string]map[string][][]int{
+		"номер файла за пределами Files": {"open": {{5, 1}}},
+		"отрицательный номер файла":      {"open": {{-1, 1}}},
+		"пустое вхождение":               {"open": {{}}},
+		"нулевая строка":                 {"open": {{0, 0}}},
+		"строки не по возрастанию":       {"open": {{0, 7, 3}}},
+		"повтор строки":                  {"open": {{0, 3, 3}}},
+	} {
+		idx := &model.ContentIndex{Schema: model.ContentIndexSchema, Files: []string{"/a.txt"}, Terms: terms}
+		if _, err := NewContentSearcher(idx); err == nil {
+			t.Errorf("%s: повреждённый индекс принят", name)
+		}
+	}
+
+	// файл повторён у слова: строки сливаются в один отсортированный список
+	idx := &model.ContentIndex{Schema: model.ContentIndexSchema, Files: []string{"/a.txt"}, Terms: map[string][][]int{
+		"open": {{0, 5, 9}, {0, 2, 5}},
+		"file": {{0, 2, 9}},
+	}}
+	s, err := NewContentSearcher(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Find("open file"); fmt.Sprint(got) != "map[/a.txt:[2 9]]" {
+		t.Errorf("повтор файла у слова: %v", got)
+	}
+}
+
+func TestSearchIn_Content(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	main := write("main.go", "package main\n\nfunc main() {\n\tpanic(\"TODO: remove\")\n}\n")
+	notes := write("notes.txt", "todo: buy milk\n")
+	b := NewContentIndexBuilder(1 << 10)
+	b.AddFile(main)
+	b.AddFile(notes)
+	s, _ := NewContentSearcher(b.Build())
+	load := func() (*ContentSearcher, error) { return s, nil }
+
+	root := model.FileInfo{IsDir: true, FullPath: dir, FullPathOrig: dir, Children: []model.FileInfo{
+		{FullName: "main.go", FullPath: main, FullPathOrig: main, Ext: "go", FileType: "code"},
+		{FullName: "notes.txt", FullPath: notes, FullPathOrig: notes, Ext: "txt", FileType: "text"},
+	}}
+
+	res, err := SearchIn(TreeSource(&root), SearchParams{Content: "todo", ContentIndex: load, Recursive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 2 {
+		t.Fatalf("content=todo: %+v", res)
+	}
+	if sn := res.Results[0].Snippets; len(sn) != 1 || sn[0].Line != 4 || sn[0].Text != `panic("TODO: remove")` {
+		t.Errorf("строка с совпадением: %+v", sn)
+	}
+
+	res, err = SearchIn(TreeSource(&root), SearchParams{Query: `content:todo AND NOT content:"buy milk"`, ContentIndex: load, Recursive: true})
+	if err != nil || res.Total != 1 || res.Results[0].FullPathOrig != main {
+		t.Errorf("content: в запросе: %v %+v", err, res)
+	}
+
+	if _, err := SearchIn(TreeSource(&root), SearchParams{Query: "content:todo", Recursive: true}); err == nil {
+		t.Error("без индекса content: должен быть ошибкой")
+	}
+}
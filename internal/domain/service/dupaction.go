@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Действия над дубликатами
+const (
+	DupHardlink = "hardlink" // заменить жёсткой ссылкой на оставляемый файл
+	DupReflink  = "reflink"  // заменить copy-on-write копией (FICLONE)
+	DupDelete   = "delete"   // удалить
+)
+
+// Политики выбора файла, который остаётся в группе
+const (
+	KeepOldest   = "oldest"   // самый старый по времени изменения
+	KeepNewest   = "newest"   // самый новый
+	KeepShortest = "shortest" // самый короткий путь
+	KeepPriority = "priority" // первый по списку приоритетных путей (иначе — самый короткий путь)
+	KeepInside   = "inside"   // находящийся внутри заданных директорий (иначе группа не трогается)
+)
+
+// KeepPolicy — как выбирать оставляемый файл; Paths нужны для priority и inside
+type KeepPolicy struct {
+	Mode  string
+	Paths []string
+}
+
+// DupFile — файл группы дубликатов в том виде, в каком он сейчас на диске
+type DupFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// DupAction — запланированное действие: Target заменяется (или удаляется) в пользу Keep
+type DupAction struct {
+	Op     string `json:"op"`
+	Keep   string `json:"keep"`
+	Target string `json:"target"`
+	Size   int64  `json:"size"`
+	Hash   string `json:"md5"`
+}
+
+// ParseDupAction проверяет название действия
+func ParseDupAction(op string) (string, error) {
+	switch op = strings.ToLower(strings.TrimSpace(op)); op {
+	case DupHardlink, DupReflink, DupDelete:
+		return op, nil
+	}
+	return "", fmt.Errorf("неизвестное действие %q (поддерживаются: %s, %s, %s)", op, DupHardlink, DupReflink, DupDelete)
+}
+
+// ParseKeepPolicy проверяет политику; priority и inside требуют список путей
+func ParseKeepPolicy(mode string, paths []string) (KeepPolicy, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case KeepOldest, KeepNewest, KeepShortest:
+		return KeepPolicy{Mode: mode}, nil
+	case KeepPriority, KeepInside:
+		if len(paths) == 0 {
+			return KeepPolicy{}, fmt.Errorf("политика %s требует список путей", mode)
+		}
+		clean := make([]string, len(paths))
+		for i, p := range paths {
+			clean[i] = filepath.Clean(p)
+		}
+		return KeepPolicy{Mode: mode, Paths: clean}, nil
+	}
+	return KeepPolicy{}, fmt.Errorf("неизвестная политика %q (поддерживаются: %s, %s, %s, %s, %s)",
+		mode, KeepOldest, KeepNewest, KeepShortest, KeepPriority, KeepInside)
+}
+
+// ChooseKeeper возвращает индекс оставляемого файла; false — ни один файл
+// не подходит под политику (например, inside, а вне директории все копии).
+// При равенстве выигрывает путь, меньший лексикографически, — выбор воспроизводим.
+func ChooseKeeper(files []DupFile, p KeepPolicy) (int, bool) {
+	best := -1
+	rank := func(f DupFile) (int64, bool) {
+		switch p.Mode {
+		case KeepOldest:
+			return f.ModTime.UnixNano(), true
+		case KeepNewest:
+			return -f.ModTime.UnixNano(), true
+		case KeepShortest:
+			return int64(len(f.Path)), true
+		case KeepPriority, KeepInside:
+			// внутри одного приоритета — самый короткий путь
+			for i, dir := range p.Paths {
+				if isInside(f.Path, dir) {
+					return int64(i)<<32 | int64(len(f.Path)), true
+				}
+			}
+			if p.Mode == KeepPriority {
+				return int64(len(p.Paths))<<32 | int64(len(f.Path)), true
+			}
+		}
+		return 0, false
+	}
+	var bestRank int64
+	for i, f := range files {
+		r, ok := rank(f)
+		if !ok {
+			continue
+		}
+		if best < 0 || r < bestRank || (r == bestRank && f.Path < files[best].Path) {
+			best, bestRank = i, r
+		}
+	}
+	return best, best >= 0
+}
+
+func isInside(path, dir string) bool {
+	path = filepath.Clean(path)
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// ShellQuote экранирует строку для POSIX sh
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// DuplicateScript — shell-скрипт с теми же действиями для просмотра и ручного запуска.
+// Каждая команда выполняется, только если файлы всё ещё побайтно равны (cmp).
+func DuplicateScript(actions []DupAction, policy KeepPolicy) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# fsjson: действия над дубликатами, политика %s", policy.Mode)
+	if len(policy.Paths) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(policy.Paths, ", "))
+	}
+	b.WriteString("\n")
+
+	keep := ""
+	for _, a := range actions {
+		if a.Keep != keep {
+			keep = a.Keep
+			fmt.Fprintf(&b, "\n# оставить %s (md5 %s)\n", ShellQuote(keep), a.Hash)
+		}
+		k, t := ShellQuote(a.Keep), ShellQuote(a.Target)
+		var cmd string
+		switch a.Op {
+		case DupHardlink:
+			cmd = fmt.Sprintf("ln -f -- %s %s", k, t)
+		case DupReflink:
+			cmd = fmt.Sprintf("cp --reflink=always -- %s %s", k, t)
+		case DupDelete:
+			cmd = fmt.Sprintf("rm -f -- %s", t)
+		}
+		fmt.Fprintf(&b, "cmp -s -- %s %s && %s\n", k, t, cmd)
+	}
+	return b.String()
+}
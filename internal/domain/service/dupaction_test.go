@@ -0,0 +1,53 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChooseKeeper(t *testing.T) {
+	now := time.Now()
+	files := []DupFile{
+		{Path: "/backup/old/photos/a.jpg", ModTime: now.Add(-time.Hour)},
+		{Path: "/photos/a.jpg", ModTime: now},
+		{Path: "/tmp/a.jpg", ModTime: now.Add(-2 * time.Hour)},
+	}
+	cases := []struct {
+		mode  string
+		paths []string
+		want  int
+		ok    bool
+	}{
+		{KeepOldest, nil, 2, true},
+		{KeepNewest, nil, 1, true},
+		{KeepShortest, nil, 2, true},
+		{KeepPriority, []string{"/backup", "/photos"}, 0, true},
+		{KeepPriority, []string{"/nowhere"}, 2, true},
+		{KeepInside, []string{"/photos"}, 1, true},
+		{KeepInside, []string{"/nowhere"}, -1, false},
+	}
+	for _, c := range cases {
+		p, err := ParseKeepPolicy(c.mode, c.paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := ChooseKeeper(files, p)
+		if got != c.want || ok != c.ok {
+			t.Errorf("%s %v: ожидалось %d %v, получено %d %v", c.mode, c.paths, c.want, c.ok, got, ok)
+		}
+	}
+	if _, err := ParseKeepPolicy(KeepInside, nil); err == nil {
+		t.Error("inside без путей должна давать ошибку")
+	}
+}
+
+func TestDuplicateScript_Quoting(t *testing.T) {
+	script := DuplicateScript([]DupAction{
+		{Op: DupHardlink, Keep: "/a/it's.txt", Target: "/b/$(rm -rf).txt"},
+	}, KeepPolicy{Mode: KeepShortest})
+	want := `cmp -s -- '/a/it'\''s.txt' '/b/$(rm -rf).txt' && ln -f -- '/a/it'\''s.txt' '/b/$(rm -rf).txt'`
+	if !strings.Contains(script, want) {
+		t.Fatalf("ожидалась строка\n%s\nв скрипте\n%s", want, script)
+	}
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"sort"
+
+	"fsjson/internal/domain/model"
+)
+
+// DuplicateDirGroup — группа одинаковых по содержимому директорий
+type DuplicateDirGroup struct {
+	Hash        string   `json:"hash"`
+	Dirs        []string `json:"dirs"`
+	Count       int      `json:"count"`
+	Size        int64    `json:"size"`        // размер одной копии
+	Files       int64    `json:"files"`       // файлов в одной копии
+	Reclaimable int64    `json:"reclaimable"` // освободится, если оставить одну копию
+}
+
+// SimilarDirPair — пара директорий с частично совпадающим содержимым
+type SimilarDirPair struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	SizeA      int64   `json:"size_a"`
+	SizeB      int64   `json:"size_b"`
+	Shared     int64   `json:"shared"`     // байт в файлах, которые есть в обеих
+	Similarity float64 `json:"similarity"` // Shared от большей директории, %
+}
+
+// DuplicateDirsResponse — результат поиска одинаковых директорий
+type DuplicateDirsResponse struct {
+	HashAlgo    string              `json:"hash_algo"`
+	Groups      []DuplicateDirGroup `json:"groups"`
+	Similar     []SimilarDirPair    `json:"similar,omitempty"`
+	Reclaimable int64               `json:"reclaimable"`
+}
+
+// dirNode — директория дерева в порядке pre-order обхода
+type dirNode struct {
+	node   *model.FileInfo
+	parent int   // индекс родителя, -1 у корня
+	end    int   // последний индекс поддерева (для проверки "предок-потомок")
+	files  int64 // файлов в поддереве
+}
+
+// FindDuplicateDirs ищет директории с одинаковым содержимым по Merkle-хешам
+// (пересчитываются при загрузке, так что подходят и старые снимки).
+// Вложенные совпадения схлопываются: если одинаковы A и B, то одинаковые
+// A/x и B/x отдельно не показываются, а если A/x совпадает ещё и с C,
+// в группе будут только A/x и C. При minSimilarity в (0, 100)
+// дополнительно ищутся пары директорий, совпадающих не менее чем на minSimilarity%
+// по объёму общих файлов.
+func FindDuplicateDirs(root *model.FileInfo, minSimilarity float64) DuplicateDirsResponse {
+	algos := TreeHashAlgorithms(root)
+	if len(algos) == 0 {
+		return DuplicateDirsResponse{}
+	}
+	algo := algos[0]
+	ComputeMerkleHashes(root, []string{algo})
+
+	var dirs []dirNode
+	var collect func(n *model.FileInfo, parent int) int64
+	collect = func(n *model.FileInfo, parent int) int64 {
+		i := len(dirs)
+		dirs = append(dirs, dirNode{node: n, parent: parent})
+		var files int64
+		for c := range n.Children {
+			if n.Children[c].IsDir {
+				files += collect(&n.Children[c], i)
+			} else {
+				files++
+			}
+		}
+		dirs[i].end = len(dirs) - 1
+		dirs[i].files = files
+		return files
+	}
+	if root.IsDir {
+		collect(root, -1)
+	}
+
+	byHash := make(map[string][]int)
+	for i, d := range dirs {
+		if h := NodeHash(d.node, algo); h != "" && d.node.SizeBytes > 0 {
+			byHash[h] = append(byHash[h], i)
+		}
+	}
+	isDup := func(i int) bool {
+		return i >= 0 && len(byHash[NodeHash(dirs[i].node, algo)]) > 1
+	}
+	// члены групп идут в порядке обхода, первая копия — первая в списке
+	isFirstCopy := func(i int) bool {
+		return byHash[NodeHash(dirs[i].node, algo)][0] == i
+	}
+
+	resp := DuplicateDirsResponse{HashAlgo: algo, Groups: []DuplicateDirGroup{}}
+	for h, members := range byHash {
+		if len(members) < 2 {
+			continue
+		}
+		// копии внутри уже одинаковых родителей схлопываются: показываются только те,
+		// что лежат в первой копии родителя, остальные повторяют её
+		var shown []int
+		for _, m := range members {
+			if p := dirs[m].parent; !isDup(p) || isFirstCopy(p) {
+				shown = append(shown, m)
+			}
+		}
+		if len(shown) < 2 {
+			continue
+		}
+		first := dirs[shown[0]]
+		g := DuplicateDirGroup{Hash: h, Count: len(shown), Size: first.node.SizeBytes, Files: first.files}
+		for _, m := range shown {
+			g.Dirs = append(g.Dirs, dirs[m].node.FullPathOrig)
+		}
+		sort.Strings(g.Dirs)
+		g.Reclaimable = g.Size * int64(g.Count-1)
+		resp.Reclaimable += g.Reclaimable
+		resp.Groups = append(resp.Groups, g)
+	}
+	sort.Slice(resp.Groups, func(i, j int) bool {
+		if resp.Groups[i].Reclaimable != resp.Groups[j].Reclaimable {
+			return resp.Groups[i].Reclaimable > resp.Groups[j].Reclaimable
+		}
+		return resp.Groups[i].Dirs[0] < resp.Groups[j].Dirs[0]
+	})
+
+	if minSimilarity > 0 && minSimilarity < 100 {
+		// из полных копий с остальными сравнивается только первая
+		skip := make([]bool, len(dirs))
+		for _, members := range byHash {
+			sort.Ints(members)
+			for _, m := range members[1:] {
+				for k := m; k <= dirs[m].end; k++ {
+					skip[k] = true
+				}
+			}
+		}
+		resp.Similar = findSimilarDirs(dirs, algo, minSimilarity, skip)
+	}
+	return resp
+}
+
+// fileCopies — сколько раз файл с данным хешем встречается в директории
+type fileCopies struct {
+	count int64
+	size  int64
+}
+
+// findSimilarDirs сравнивает только директории, у которых есть общие файлы
+// (через индекс хеш → директории); предки и потомки между собой не сравниваются,
+// поддеревья с skip пропускаются
+func findSimilarDirs(dirs []dirNode, algo string, minSimilarity float64, skip []bool) []SimilarDirPair {
+	contents := make([]map[string]fileCopies, len(dirs))
+	for i := range dirs {
+		m := make(map[string]fileCopies)
+		for c := range dirs[i].node.Children {
+			f := &dirs[i].node.Children[c]
+			if h := NodeHash(f, algo); !f.IsDir && h != "" {
+				m[h] = fileCopies{count: m[h].count + 1, size: f.SizeBytes}
+			}
+		}
+		contents[i] = m
+	}
+	// потомки идут после предков: к моменту слияния в родителя поддерево уже собрано
+	for i := len(dirs) - 1; i > 0; i-- {
+		parent := contents[dirs[i].parent]
+		for h, fc := range contents[i] {
+			parent[h] = fileCopies{count: parent[h].count + fc.count, size: fc.size}
+		}
+	}
+
+	index := make(map[string][]int)
+	for i, m := range contents {
+		for h := range m {
+			index[h] = append(index[h], i)
+		}
+	}
+
+	type pair struct{ a, b int }
+	similar := make(map[pair]SimilarDirPair)
+	for i, m := range contents {
+		if skip[i] {
+			continue
+		}
+		shared := make(map[int]int64)
+		for h, fc := range m {
+			for _, j := range index[h] {
+				if j <= dirs[i].end || skip[j] { // уже сравнивали или j внутри i
+					continue
+				}
+				shared[j] += min(fc.count, contents[j][h].count) * fc.size
+			}
+		}
+		a := dirs[i].node
+		for j, s := range shared {
+			b := dirs[j].node
+			if NodeHash(a, algo) != "" && NodeHash(a, algo) == NodeHash(b, algo) {
+				continue // полные копии уже в группах
+			}
+			larger := max(a.SizeBytes, b.SizeBytes)
+			if larger == 0 {
+				continue
+			}
+			pct := float64(s) * 100 / float64(larger)
+			if pct < minSimilarity {
+				continue
+			}
+			similar[pair{i, j}] = SimilarDirPair{
+				A: a.FullPathOrig, B: b.FullPathOrig,
+				SizeA: a.SizeBytes, SizeB: b.SizeBytes,
+				Shared: s, Similarity: pct,
+			}
+		}
+	}
+
+	// показываем только верхние пары: если похожа и пара, где одну или обе директории
+	// заменили родителями, то общие файлы уже учтены в ней
+	lifted := func(a, b int) bool {
+		if a < 0 || b < 0 {
+			return false
+		}
+		_, ok := similar[pair{min(a, b), max(a, b)}]
+		return ok
+	}
+	var out []SimilarDirPair
+	for p, sp := range similar {
+		pa, pb := dirs[p.a].parent, dirs[p.b].parent
+		if lifted(pa, p.b) || lifted(p.a, pb) || lifted(pa, pb) {
+			continue
+		}
+		out = append(out, sp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Shared != out[j].Shared {
+			return out[i].Shared > out[j].Shared
+		}
+		return out[i].A < out[j].A
+	})
+	return out
+}
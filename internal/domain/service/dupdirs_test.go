@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func dupFile(dir, name, md5 string, size int64) model.FileInfo {
+	return model.FileInfo{FullName: name, FullPathOrig: dir + "/" + name, Md5: md5, SizeBytes: size}
+}
+
+func dupDir(path, name string, children ...model.FileInfo) model.FileInfo {
+	d := model.FileInfo{IsDir: true, FullName: name, FullPathOrig: path, Children: children}
+	for _, c := range children {
+		d.SizeBytes += c.SizeBytes
+	}
+	return d
+}
+
+func TestFindDuplicateDirs(t *testing.T) {
+	// p и q одинаковы целиком, s/a совпадает с p/a и q/a, r похожа на p наполовину
+	root := dupDir("/t", "t",
+		dupDir("/t/p", "p", dupDir("/t/p/a", "a", dupFile("/t/p/a", "1", "h1", 10)), dupDir("/t/p/b", "b", dupFile("/t/p/b", "2", "h2", 10))),
+		dupDir("/t/q", "q", dupDir("/t/q/a", "a", dupFile("/t/q/a", "1", "h1", 10)), dupDir("/t/q/b", "b", dupFile("/t/q/b", "2", "h2", 10))),
+		dupDir("/t/r", "r", dupDir("/t/r/a", "a", dupFile("/t/r/a", "1", "h1", 10), dupFile("/t/r/a", "3", "h3", 10))),
+		dupDir("/t/s", "s", dupDir("/t/s/a", "a", dupFile("/t/s/a", "1", "h1", 10)), dupFile("/t/s", "big", "h4", 100)),
+	)
+
+	res := FindDuplicateDirs(&root, 100)
+	if len(res.Groups) != 2 {
+		t.Fatalf("ожидалось 2 группы, получено %+v", res.Groups)
+	}
+	if g := res.Groups[0]; g.Count != 2 || g.Dirs[0] != "/t/p" || g.Dirs[1] != "/t/q" || g.Reclaimable != 20 {
+		t.Fatalf("первая группа: %+v", g)
+	}
+	// p/b и q/b лежат внутри одинаковых p и q — отдельно не показываются
+	// p/a, q/a и s/a: копии внутри p/q схлопываются в одну, освобождается одна копия
+	if g := res.Groups[1]; g.Count != 2 || g.Dirs[0] != "/t/p/a" || g.Dirs[1] != "/t/s/a" || g.Reclaimable != 10 {
+		t.Fatalf("вторая группа: %+v", g)
+	}
+	if res.Reclaimable != 30 || res.Similar != nil {
+		t.Fatalf("итог: %+v", res)
+	}
+
+	res = FindDuplicateDirs(&root, 50)
+	if len(res.Similar) != 1 {
+		t.Fatalf("ожидалась одна похожая пара, получено %+v", res.Similar)
+	}
+	if p := res.Similar[0]; p.A != "/t/p" || p.B != "/t/r" || p.Shared != 10 || p.Similarity != 50 {
+		t.Fatalf("похожая пара: %+v", p)
+	}
+}
+
+func TestFindDuplicateDirs_NestedInDuplicateParents(t *testing.T) {
+	// p и q одинаковы, внутри каждой — одинаковые a и b: показывается только пара p, q
+	root := dupDir("/t", "t",
+		dupDir("/t/p", "p",
+			dupDir("/t/p/a", "a", dupFile("/t/p/a", "1", "h1", 10)),
+			dupDir("/t/p/b", "b", dupFile("/t/p/b", "1", "h1", 10))),
+		dupDir("/t/q", "q",
+			dupDir("/t/q/a", "a", dupFile("/t/q/a", "1", "h1", 10)),
+			dupDir("/t/q/b", "b", dupFile("/t/q/b", "1", "h1", 10))),
+	)
+
+	res := FindDuplicateDirs(&root, 100)
+	if len(res.Groups) != 2 {
+		t.Fatalf("ожидалось 2 группы, получено %+v", res.Groups)
+	}
+	if g := res.Groups[0]; g.Count != 2 || g.Dirs[0] != "/t/p" || g.Dirs[1] != "/t/q" || g.Reclaimable != 20 {
+		t.Fatalf("группа родителей: %+v", g)
+	}
+	// a и b одинаковы и внутри p, и внутри q: от q остаются только его копии на уровне p
+	if g := res.Groups[1]; g.Count != 2 || g.Dirs[0] != "/t/p/a" || g.Dirs[1] != "/t/p/b" || g.Reclaimable != 10 {
+		t.Fatalf("вложенная группа: %+v", g)
+	}
+	if res.Reclaimable != 30 {
+		t.Fatalf("итог: %+v", res)
+	}
+}
@@ -1,68 +1,292 @@
 package service
 
 import (
+	"fmt"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"fsjson/internal/domain/model"
 )
 
+// Сортировка групп дубликатов
+const (
+	DupSortWasted = "wasted" // по лишнему месту (по умолчанию)
+	DupSortCount  = "count"  // по количеству копий
+	DupSortSize   = "size"   // по размеру одного файла
+)
+
 // DuplicateGroup — группа файлов с одинаковым MD5
 type DuplicateGroup struct {
-	Md5   string   `json:"md5"`
-	Files []string `json:"files"`
-	Count int      `json:"count"`
-	Size  int64    `json:"size"`
+	Md5      string   `json:"md5"`
+	Files    []string `json:"files"`
+	Count    int      `json:"count"`
+	Size     int64    `json:"size"`      // суммарно всех копий
+	FileSize int64    `json:"file_size"` // одной копии
+	Wasted   int64    `json:"wasted"`    // лишнее место: FileSize × (Count−1)
+
+	// при поиске по нескольким снимкам — где лежит каждая копия (в порядке Files)
+	Locations []DuplicateLocation `json:"locations,omitempty"`
+	Sources   int                 `json:"sources,omitempty"` // в скольких снимках есть копии
+}
+
+// DuplicateLocation — копия файла в конкретном снимке
+type DuplicateLocation struct {
+	Snapshot string `json:"snapshot"`
+	Path     string `json:"path"`
+}
+
+// SnapshotDuplicates — сколько дублированных данных в каждом снимке
+type SnapshotDuplicates struct {
+	Snapshot string `json:"snapshot"`
+	Files    int    `json:"files"`
+	Bytes    int64  `json:"bytes"`
+	Only     int    `json:"only"` // групп, все копии которых только в этом снимке
+}
+
+// DuplicateDirRollup — сколько дублированных данных лежит в директории
+type DuplicateDirRollup struct {
+	Dir   string `json:"dir"`
+	Files int    `json:"files"` // файлов, у которых есть копии
+	Bytes int64  `json:"bytes"` // их суммарный размер
 }
 
-// DuplicatesResponse — результат поиска дубликатов
+// DuplicatesResponse — результат поиска дубликатов.
+// Итоги считаются по всем найденным группам, Groups — запрошенная страница.
 type DuplicatesResponse struct {
-	Groups []DuplicateGroup `json:"groups"`
-	Total  int              `json:"total_groups"`
-	Files  int              `json:"total_files"`
+	Groups []DuplicateGroup     `json:"groups"`
+	Total  int                  `json:"total_groups"`
+	Files  int                  `json:"total_files"`
+	Wasted int64                `json:"wasted_bytes"`
+	Dirs   []DuplicateDirRollup `json:"dirs,omitempty"`
+
+	Snapshots []SnapshotDuplicates `json:"snapshots,omitempty"` // при поиске по нескольким снимкам
+}
+
+// DuplicateFilter — какие файлы учитывать и как оформить отчёт
+type DuplicateFilter struct {
+	MinSize    int64    // минимальный размер файла, байт
+	Types      []string // FileType
+	Exts       []string // расширения без точки
+	PathPrefix string   // только файлы с путём FullPath, начинающимся с префикса
+	Sort       string   // wasted | count | size
+	Limit      int      // 0 — все группы
+	Offset     int
+	TopDirs    int  // сколько директорий показать в сводке (0 — без сводки)
+	CrossOnly  bool // только группы с копиями в разных снимках
+}
+
+// ParseDuplicateSort проверяет способ сортировки ("" — по лишнему месту)
+func ParseDuplicateSort(s string) (string, error) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "":
+		return DupSortWasted, nil
+	case DupSortWasted, DupSortCount, DupSortSize:
+		return s, nil
+	}
+	return "", fmt.Errorf("неизвестная сортировка %q (поддерживаются: %s, %s, %s)", s, DupSortWasted, DupSortCount, DupSortSize)
+}
+
+// Match — подходит ли файл под фильтры
+func (f DuplicateFilter) Match(n *model.FileInfo) bool {
+	if n.IsDir || n.SizeBytes < f.MinSize {
+		return false
+	}
+	if f.PathPrefix != "" && !strings.HasPrefix(n.FullPath, f.PathPrefix) {
+		return false
+	}
+	if len(f.Types) > 0 && !containsFold(f.Types, n.FileType) {
+		return false
+	}
+	if len(f.Exts) > 0 && !containsFold(f.Exts, strings.TrimPrefix(n.Ext, ".")) {
+		return false
+	}
+	return true
+}
+
+// prune — директория целиком вне PathPrefix (ни предок, ни потомок префикса)
+func (f DuplicateFilter) prune(n *model.FileInfo) bool {
+	return f.PathPrefix != "" && n.IsDir &&
+		!strings.HasPrefix(f.PathPrefix, n.FullPath) && !strings.HasPrefix(n.FullPath, f.PathPrefix)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if v = strings.TrimPrefix(strings.TrimSpace(v), "."); v != "" && strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
 }
 
 // FindDuplicates — ищет все файлы с одинаковым MD5
 func FindDuplicates(root *model.FileInfo) DuplicatesResponse {
-	md5map := make(map[string][]*model.FileInfo)
+	resp, _ := FindDuplicatesIn(TreeSource(root), DuplicateFilter{})
+	return resp
+}
+
+// dupEntry — минимум сведений о файле, нужный для группировки
+type dupEntry struct {
+	path string
+	size int64
+	src  int // номер источника
+}
+
+// FindDuplicatesIn ищет дубликаты в произвольном источнике узлов за два прохода:
+// сначала считаются только количества по MD5, затем запоминаются пути
+// лишь тех файлов, чей хеш встречается больше одного раза.
+// Учитываются только файлы, подходящие под фильтр.
+func FindDuplicatesIn(src NodeSource, filter DuplicateFilter) (DuplicatesResponse, error) {
+	return findDuplicates([]NodeSource{src}, nil, filter)
+}
+
+// FindDuplicatesAcross ищет дубликаты сразу в нескольких снимках без их объединения:
+// у каждой копии указан снимок (labels[i] — подпись i-го источника), а в итогах —
+// сколько дублированных данных приходится на каждый снимок
+func FindDuplicatesAcross(srcs []NodeSource, labels []string, filter DuplicateFilter) (DuplicatesResponse, error) {
+	return findDuplicates(srcs, labels, filter)
+}
 
-	var walk func(n *model.FileInfo)
-	walk = func(n *model.FileInfo) {
-		if n == nil {
-			return
+func findDuplicates(srcs []NodeSource, labels []string, filter DuplicateFilter) (DuplicatesResponse, error) {
+	visit := func(fn func(n *model.FileInfo)) NodeVisitor {
+		return func(n *model.FileInfo, _ int) error {
+			if filter.prune(n) {
+				return ErrSkipChildren
+			}
+			if n.Md5 != "" && filter.Match(n) {
+				fn(n)
+			}
+			return nil
 		}
-		if !n.IsDir && n.Md5 != "" {
-			md5map[n.Md5] = append(md5map[n.Md5], n)
+	}
+
+	counts := make(map[string]int32)
+	for _, src := range srcs {
+		if err := src(visit(func(n *model.FileInfo) { counts[n.Md5]++ })); err != nil {
+			return DuplicatesResponse{}, err
 		}
-		for i := range n.Children {
-			walk(&n.Children[i])
+	}
+
+	md5map := make(map[string][]dupEntry)
+	for i, src := range srcs {
+		err := src(visit(func(n *model.FileInfo) {
+			if counts[n.Md5] > 1 {
+				md5map[n.Md5] = append(md5map[n.Md5], dupEntry{path: n.FullPathOrig, size: n.SizeBytes, src: i})
+			}
+		}))
+		if err != nil {
+			return DuplicatesResponse{}, err
 		}
 	}
-	walk(root)
 
 	groups := make([]DuplicateGroup, 0, len(md5map))
-	totalFiles := 0
+	perSource := make([]SnapshotDuplicates, len(labels))
+	for i, l := range labels {
+		perSource[i].Snapshot = l
+	}
 	for md5, files := range md5map {
-		if len(files) > 1 { // только дубликаты
-			group := DuplicateGroup{Md5: md5, Count: len(files)}
-			for _, f := range files {
-				group.Files = append(group.Files, f.FullPathOrig)
-				group.Size += f.SizeBytes
+		if len(files) < 2 { // только дубликаты
+			continue
+		}
+		group := DuplicateGroup{Md5: md5, Count: len(files), FileSize: files[0].size}
+		seen := make(map[int]bool)
+		for _, f := range files {
+			seen[f.src] = true
+		}
+		if labels != nil && filter.CrossOnly && len(seen) < 2 {
+			continue
+		}
+		for _, f := range files {
+			group.Files = append(group.Files, f.path)
+			group.Size += f.size
+			if labels != nil {
+				group.Locations = append(group.Locations, DuplicateLocation{Snapshot: labels[f.src], Path: f.path})
+				perSource[f.src].Files++
+				perSource[f.src].Bytes += f.size
+			}
+		}
+		if labels != nil {
+			group.Sources = len(seen)
+			if len(seen) == 1 {
+				perSource[files[0].src].Only++
 			}
-			totalFiles += len(files)
-			groups = append(groups, group)
+		}
+		groups = append(groups, group)
+	}
+	resp := duplicatesReport(groups, filter)
+	if labels != nil {
+		resp.Snapshots = perSource
+	}
+	return resp, nil
+}
+
+// duplicatesReport считает лишнее место и сводку по директориям по всем группам,
+// сортирует их и вырезает страницу
+func duplicatesReport(groups []DuplicateGroup, filter DuplicateFilter) DuplicatesResponse {
+	resp := DuplicatesResponse{Total: len(groups)}
+	dirs := make(map[string]*DuplicateDirRollup)
+	for i := range groups {
+		g := &groups[i]
+		g.Wasted = g.FileSize * int64(g.Count-1)
+		resp.Files += g.Count
+		resp.Wasted += g.Wasted
+		if filter.TopDirs <= 0 {
+			continue
+		}
+		for k, f := range g.Files {
+			dir := filepath.Dir(f)
+			if g.Locations != nil {
+				dir = g.Locations[k].Snapshot + ": " + dir
+			}
+			d := dirs[dir]
+			if d == nil {
+				d = &DuplicateDirRollup{Dir: dir}
+				dirs[dir] = d
+			}
+			d.Files++
+			d.Bytes += g.FileSize
 		}
 	}
 
+	less := func(a, b *DuplicateGroup) bool { return a.Wasted > b.Wasted }
+	switch filter.Sort {
+	case DupSortCount:
+		less = func(a, b *DuplicateGroup) bool { return a.Count > b.Count }
+	case DupSortSize:
+		less = func(a, b *DuplicateGroup) bool { return a.FileSize > b.FileSize }
+	}
 	sort.Slice(groups, func(i, j int) bool {
-		if groups[i].Count == groups[j].Count {
-			return groups[i].Size > groups[j].Size
+		a, b := &groups[i], &groups[j]
+		if less(a, b) || less(b, a) {
+			return less(a, b)
+		}
+		if a.Wasted != b.Wasted {
+			return a.Wasted > b.Wasted
 		}
-		return groups[i].Count > groups[j].Count
+		return a.Md5 < b.Md5
 	})
 
-	return DuplicatesResponse{
-		Groups: groups,
-		Total:  len(groups),
-		Files:  totalFiles,
+	start := min(max(filter.Offset, 0), len(groups))
+	end := len(groups)
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, end)
+	}
+	resp.Groups = groups[start:end]
+
+	if filter.TopDirs > 0 {
+		resp.Dirs = make([]DuplicateDirRollup, 0, len(dirs))
+		for _, d := range dirs {
+			resp.Dirs = append(resp.Dirs, *d)
+		}
+		sort.Slice(resp.Dirs, func(i, j int) bool {
+			if resp.Dirs[i].Bytes != resp.Dirs[j].Bytes {
+				return resp.Dirs[i].Bytes > resp.Dirs[j].Bytes
+			}
+			return resp.Dirs[i].Dir < resp.Dirs[j].Dir
+		})
+		if len(resp.Dirs) > filter.TopDirs {
+			resp.Dirs = resp.Dirs[:filter.TopDirs]
+		}
 	}
+	return resp
 }
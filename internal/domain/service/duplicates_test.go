@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func dupTree() model.FileInfo {
+	file := func(path, ext, typ, md5 string, size int64) model.FileInfo {
+		return model.FileInfo{FullPath: path, FullPathOrig: path, Ext: ext, FileType: typ, Md5: md5, SizeBytes: size}
+	}
+	return model.FileInfo{IsDir: true, FullPath: "/r", Children: []model.FileInfo{
+		{IsDir: true, FullPath: "/r/photos", Children: []model.FileInfo{
+			file("/r/photos/a.jpg", "jpg", "image", "img", 1000),
+			file("/r/photos/b.jpg", "jpg", "image", "img", 1000),
+			file("/r/photos/n.txt", "txt", "text", "txt", 10),
+		}},
+		{IsDir: true, FullPath: "/r/backup", Children: []model.FileInfo{
+			file("/r/backup/a.jpg", "jpg", "image", "img", 1000),
+			file("/r/backup/n.txt", "txt", "text", "txt", 10),
+			file("/r/backup/m.txt", "txt", "text", "txt", 10),
+		}},
+	}}
+}
+
+func TestFindDuplicatesIn_WastedAndFilters(t *testing.T) {
+	root := dupTree()
+
+	res, err := FindDuplicatesIn(TreeSource(&root), DuplicateFilter{TopDirs: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 2 || res.Files != 6 || res.Wasted != 2020 {
+		t.Fatalf("итоги: %+v", res)
+	}
+	if g := res.Groups[0]; g.Md5 != "img" || g.Size != 3000 || g.FileSize != 1000 || g.Wasted != 2000 {
+		t.Fatalf("первой должна идти группа с наибольшим лишним местом: %+v", g)
+	}
+	if len(res.Dirs) != 1 || res.Dirs[0].Dir != "/r/photos" || res.Dirs[0].Bytes != 2010 {
+		t.Fatalf("сводка по директориям: %+v", res.Dirs)
+	}
+
+	res, _ = FindDuplicatesIn(TreeSource(&root), DuplicateFilter{Sort: DupSortCount, Limit: 1, Offset: 1})
+	// поровну копий — дальше по лишнему месту
+	if res.Total != 2 || len(res.Groups) != 1 || res.Groups[0].Md5 != "txt" {
+		t.Fatalf("страница 2 при сортировке по количеству: %+v", res)
+	}
+
+	res, _ = FindDuplicatesIn(TreeSource(&root), DuplicateFilter{MinSize: 100})
+	if res.Total != 1 || res.Groups[0].Md5 != "img" {
+		t.Fatalf("min-size: %+v", res)
+	}
+
+	res, _ = FindDuplicatesIn(TreeSource(&root), DuplicateFilter{Exts: []string{".TXT"}, PathPrefix: "/r/backup"})
+	if res.Total != 1 || res.Groups[0].Count != 2 {
+		t.Fatalf("ext и path: %+v", res)
+	}
+
+	res, _ = FindDuplicatesIn(TreeSource(&root), DuplicateFilter{Types: []string{"image"}, PathPrefix: "/r/backup"})
+	if res.Total != 0 || res.Groups == nil {
+		t.Fatalf("в /r/backup одна картинка — дубликатов нет: %+v", res)
+	}
+}
+
+func TestFindDuplicatesAcross(t *testing.T) {
+	disk1 := model.FileInfo{IsDir: true, FullPath: "/d", Children: []model.FileInfo{
+		{FullPath: "/d/a.jpg", FullPathOrig: "/d/a.jpg", Md5: "img", SizeBytes: 100},
+		{FullPath: "/d/n.txt", FullPathOrig: "/d/n.txt", Md5: "txt", SizeBytes: 10},
+		{FullPath: "/d/m.txt", FullPathOrig: "/d/m.txt", Md5: "txt", SizeBytes: 10},
+	}}
+	// тот же путь на другом диске
+	disk2 := model.FileInfo{IsDir: true, FullPath: "/d", Children: []model.FileInfo{
+		{FullPath: "/d/a.jpg", FullPathOrig: "/d/a.jpg", Md5: "img", SizeBytes: 100},
+	}}
+	srcs := []NodeSource{TreeSource(&disk1), TreeSource(&disk2)}
+
+	res, err := FindDuplicatesAcross(srcs, []string{"disk1", "disk2"}, DuplicateFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 2 || len(res.Snapshots) != 2 {
+		t.Fatalf("ожидалось 2 группы и сводка по 2 снимкам: %+v", res)
+	}
+	g := res.Groups[0]
+	if g.Md5 != "img" || g.Sources != 2 || g.Locations[0].Snapshot != "disk1" || g.Locations[1].Snapshot != "disk2" {
+		t.Fatalf("копии должны быть помечены снимками: %+v", g)
+	}
+	if s := res.Snapshots[0]; s.Files != 3 || s.Bytes != 120 || s.Only != 1 {
+		t.Fatalf("сводка disk1: %+v", s)
+	}
+
+	res, _ = FindDuplicatesAcross(srcs, []string{"disk1", "disk2"}, DuplicateFilter{CrossOnly: true})
+	if res.Total != 1 || res.Groups[0].Md5 != "img" {
+		t.Fatalf("cross-only: %+v", res)
+	}
+}
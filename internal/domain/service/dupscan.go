@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+)
+
+// PartialHashChunk — сколько байт с начала и с конца файла читает PartialHash
+const PartialHashChunk = 4 * 1024
+
+// DupCandidate — файл-кандидат в дубликаты
+type DupCandidate struct {
+	Path string
+	Size int64
+}
+
+// GroupBySize — первый этап поиска дубликатов без чтения файлов:
+// остаются только группы из двух и более файлов одного размера.
+// Пустые файлы не учитываются — они все одинаковы и места не занимают.
+func GroupBySize(files []DupCandidate) [][]DupCandidate {
+	bySize := make(map[int64][]DupCandidate)
+	for _, f := range files {
+		if f.Size > 0 {
+			bySize[f.Size] = append(bySize[f.Size], f)
+		}
+	}
+	var groups [][]DupCandidate
+	for _, g := range bySize {
+		if len(g) > 1 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// SplitGroups делит каждую группу по ключу и оставляет подгруппы из двух и более файлов.
+// Файлы без ключа (ошибка чтения) отбрасываются.
+func SplitGroups(groups [][]DupCandidate, keys map[string]string) [][]DupCandidate {
+	var out [][]DupCandidate
+	for _, g := range groups {
+		byKey := make(map[string][]DupCandidate)
+		var order []string
+		for _, f := range g {
+			k := keys[f.Path]
+			if k == "" {
+				continue
+			}
+			if _, ok := byKey[k]; !ok {
+				order = append(order, k)
+			}
+			byKey[k] = append(byKey[k], f)
+		}
+		for _, k := range order {
+			if len(byKey[k]) > 1 {
+				out = append(out, byKey[k])
+			}
+		}
+	}
+	return out
+}
+
+// PartialHashIsFull — PartialHash файла такого размера покрывает всё содержимое
+func PartialHashIsFull(size int64) bool {
+	return size <= 2*PartialHashChunk
+}
+
+// PartialHash — хеш размера и первых и последних PartialHashChunk байт файла.
+// Если файл прочитан целиком (PartialHashIsFull), это обычный MD5 содержимого:
+// его можно использовать как полный хеш и не читать файл второй раз.
+func PartialHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	h := md5.New()
+	if PartialHashIsFull(st.Size()) {
+		if _, err := io.Copy(h, f); err != nil {
+			return "", err
+		}
+	} else {
+		var size [8]byte
+		binary.LittleEndian.PutUint64(size[:], uint64(st.Size()))
+		h.Write(size[:])
+		buf := make([]byte, PartialHashChunk)
+		if _, err := io.ReadFull(f, buf); err != nil {
+			return "", err
+		}
+		h.Write(buf)
+		if _, err := f.ReadAt(buf, st.Size()-PartialHashChunk); err != nil {
+			return "", err
+		}
+		h.Write(buf)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SameContent побайтно сравнивает два файла
+func SameContent(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	const chunk = 64 * 1024
+	ba, bb := make([]byte, chunk), make([]byte, chunk)
+	for {
+		na, errA := io.ReadFull(fa, ba)
+		nb, errB := io.ReadFull(fb, bb)
+		if na != nb || !bytes.Equal(ba[:na], bb[:nb]) {
+			return false, nil
+		}
+		doneA := errA == io.EOF || errA == io.ErrUnexpectedEOF
+		doneB := errB == io.EOF || errB == io.ErrUnexpectedEOF
+		if errA != nil && !doneA {
+			return false, errA
+		}
+		if errB != nil && !doneB {
+			return false, errB
+		}
+		if doneA || doneB {
+			return doneA && doneB, nil
+		}
+	}
+}
+
+// SplitByContent делит группу файлов с одинаковым хешем на классы побайтно равных
+// (защита от коллизий хеша). Файлы, которые не удалось прочитать, отбрасываются.
+func SplitByContent(group []DupCandidate, same func(a, b string) (bool, error)) [][]DupCandidate {
+	var classes [][]DupCandidate
+next:
+	for _, f := range group {
+		for i, c := range classes {
+			eq, err := same(c[0].Path, f.Path)
+			if err != nil {
+				continue next
+			}
+			if eq {
+				classes[i] = append(classes[i], f)
+				continue next
+			}
+		}
+		classes = append(classes, []DupCandidate{f})
+	}
+	out := classes[:0]
+	for _, c := range classes {
+		if len(c) > 1 {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// DuplicatesFromGroups оформляет найденные группы в тот же ответ, что и FindDuplicatesIn
+func DuplicatesFromGroups(groups [][]DupCandidate, hashes map[string]string, filter DuplicateFilter) DuplicatesResponse {
+	out := make([]DuplicateGroup, 0, len(groups))
+	for _, g := range groups {
+		group := DuplicateGroup{Md5: hashes[g[0].Path], Count: len(g), FileSize: g[0].Size}
+		for _, f := range g {
+			group.Files = append(group.Files, f.Path)
+			group.Size += f.Size
+		}
+		sort.Strings(group.Files)
+		out = append(out, group)
+	}
+	return duplicatesReport(out, filter)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDuplicatePipeline(t *testing.T) {
+	dir := t.TempDir()
+	big := bytes.Repeat([]byte("0123456789"), 2000)
+	middle := append([]byte(nil), big...)
+	middle[len(middle)/2] = 'X' // отличается только в середине
+	write := func(name string, data []byte) DupCandidate {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return DupCandidate{Path: p, Size: int64(len(data))}
+	}
+	files := []DupCandidate{
+		write("a", big), write("b", big), write("mid", middle),
+		write("small", []byte("x")), write("e1", nil), write("e2", nil),
+	}
+
+	groups := GroupBySize(files)
+	if len(groups) != 1 || len(groups[0]) != 3 {
+		t.Fatalf("по размеру ожидалась одна группа из 3 файлов (пустые не учитываются): %+v", groups)
+	}
+
+	keys := make(map[string]string)
+	for _, f := range groups[0] {
+		h, err := PartialHash(f.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[f.Path] = h
+	}
+	if got := SplitGroups(groups, keys); len(got) != 1 || len(got[0]) != 3 {
+		t.Fatalf("частичный хеш не видит середину файла — группа должна остаться: %+v", got)
+	}
+
+	classes := SplitByContent(groups[0], SameContent)
+	if len(classes) != 1 || len(classes[0]) != 2 {
+		t.Fatalf("побайтно равны только a и b: %+v", classes)
+	}
+}
+
+func TestPartialHash_SmallFileIsMD5(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small")
+	big := filepath.Join(dir, "big")
+	os.WriteFile(small, bytes.Repeat([]byte("a"), 2*PartialHashChunk), 0644)
+	os.WriteFile(big, bytes.Repeat([]byte("a"), 2*PartialHashChunk+1), 0644)
+
+	for _, c := range []struct {
+		path string
+		full bool
+	}{{small, true}, {big, false}} {
+		st, _ := os.Stat(c.path)
+		if PartialHashIsFull(st.Size()) != c.full {
+			t.Fatalf("%s: PartialHashIsFull(%d) != %v", c.path, st.Size(), c.full)
+		}
+		partial, err := PartialHash(c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sums, _ := FileHashes(c.path, []string{"md5"})
+		if (partial == sums["md5"]) != c.full {
+			t.Errorf("%s: частичный %s, MD5 %s", c.path, partial, sums["md5"])
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"fsjson/internal/domain/model"
+)
+
+// Веса нечёткого сопоставления (по мотивам fzf): совпавший символ, бонусы за начало слова
+// и за подряд идущие символы, штрафы за пропуски и за опечатку
+const (
+	fuzzyScoreMatch        = 16
+	fuzzyBonusStart        = 10 // начало строки
+	fuzzyBonusDelimiter    = 9  // после / или пробела
+	fuzzyBonusBoundary     = 8  // после _ - . и других не букв
+	fuzzyBonusCamel        = 7  // fooBar: B, a1: 1
+	fuzzyBonusConsecutive  = 4
+	fuzzyFirstCharMultiple = 2
+	fuzzyGapStart          = -3
+	fuzzyGapExtension      = -1
+	fuzzyTypoPenalty       = -24
+	fuzzyTypoMinPattern    = 4 // опечатка допускается в шаблонах от стольких символов
+)
+
+// FuzzyMatch — нечёткое сопоставление, как в fzf: символы шаблона должны встретиться в строке
+// по порядку, но не обязательно подряд ("fbr" находит "foo_bar.go"). Если так не получается,
+// в шаблоне от 4 символов допускается одна опечатка: лишний или переставленный символ.
+// Возвращает оценку (чем больше, тем лучше) и позиции совпавших символов в рунах.
+func FuzzyMatch(pattern, s string, caseSensitive bool) (score int, positions []int, ok bool) {
+	p := []rune(pattern)
+	text := []rune(s)
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	folded := text
+	if !caseSensitive {
+		p = []rune(strings.ToLower(pattern))
+		folded = make([]rune, len(text))
+		for i, r := range text {
+			folded[i] = unicode.ToLower(r)
+		}
+	}
+
+	if pos := fuzzyAlign(p, folded); pos != nil {
+		return fuzzyScore(text, pos), pos, true
+	}
+	if len(p) < fuzzyTypoMinPattern {
+		return 0, nil, false
+	}
+	// одна опечатка: пробуем без каждого из символов шаблона
+	best := 0
+	for i := range p {
+		without := append(append([]rune{}, p[:i]...), p[i+1:]...)
+		if pos := fuzzyAlign(without, folded); pos != nil {
+			if sc := fuzzyScore(text, pos) + fuzzyTypoPenalty; !ok || sc > best {
+				best, positions, ok = sc, pos, true
+			}
+		}
+	}
+	return best, positions, ok
+}
+
+// fuzzyAlign находит символы шаблона по порядку: первый проход вперёд до конца совпадения,
+// затем назад — самое короткое окно, которое заканчивается там же (как в fzf v1)
+func fuzzyAlign(p, text []rune) []int {
+	pi, end := 0, -1
+	for i, r := range text {
+		if r == p[pi] {
+			if pi++; pi == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return nil
+	}
+	pos := make([]int, len(p))
+	pi = len(p) - 1
+	for i := end; i >= 0 && pi >= 0; i-- {
+		if text[i] == p[pi] {
+			pos[pi] = i
+			pi--
+		}
+	}
+	return pos
+}
+
+// fuzzyScore — оценка совпадения по позициям символов
+func fuzzyScore(text []rune, pos []int) int {
+	score, chunk := 0, 0 // chunk — бонус первого символа текущей серии подряд идущих
+	for k, i := range pos {
+		bonus := fuzzyBonusAt(text, i)
+		if k > 0 && i == pos[k-1]+1 {
+			bonus = max(bonus, chunk, fuzzyBonusConsecutive)
+		} else {
+			chunk = bonus
+			if k > 0 {
+				gap := i - pos[k-1] - 1
+				score += fuzzyGapStart + fuzzyGapExtension*(gap-1)
+			}
+		}
+		if k == 0 {
+			bonus *= fuzzyFirstCharMultiple
+		}
+		score += fuzzyScoreMatch + bonus
+	}
+	return score
+}
+
+func fuzzyBonusAt(text []rune, i int) int {
+	if i == 0 {
+		return fuzzyBonusStart
+	}
+	prev, cur := text[i-1], text[i]
+	switch {
+	case prev == '/' || prev == '\\' || unicode.IsSpace(prev):
+		return fuzzyBonusDelimiter
+	case prev == '_' || prev == '-' || prev == '.':
+		return fuzzyBonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur), unicode.IsLetter(prev) && unicode.IsDigit(cur):
+		return fuzzyBonusCamel
+	case !unicode.IsLetter(prev) && !unicode.IsDigit(prev) && (unicode.IsLetter(cur) || unicode.IsDigit(cur)):
+		return fuzzyBonusBoundary
+	}
+	return 0
+}
+
+// fuzzyCond — условие имени или пути в режиме fuzzy, по которому ранжируются результаты
+type fuzzyCond struct {
+	pattern string
+	path    bool // сравнивается с путём, а не с именем
+	opts    MatchOptions
+}
+
+// fuzzyRank — суммарная оценка узла по условиям fuzzy и позиции совпавших символов
+// в FullPathOrig (для подсветки); условия, которым узел не подошёл (ветка OR), не учитываются
+func fuzzyRank(conds []fuzzyCond, n *model.FileInfo) (score int, matches []int) {
+	for _, c := range conds {
+		target := n.FullPath
+		if !c.path {
+			target = c.opts.target(n, c.pattern)
+		}
+		sc, pos, ok := FuzzyMatch(c.pattern, target, c.opts.CaseSensitive)
+		if !ok {
+			continue
+		}
+		score += sc
+		// позиции переносятся в FullPathOrig, если цель — его окончание (имя или тот же путь)
+		if !strings.HasSuffix(n.FullPathOrig, target) {
+			continue
+		}
+		offset := utf8.RuneCountInString(n.FullPathOrig) - utf8.RuneCountInString(target)
+		for _, p := range pos {
+			matches = append(matches, p+offset)
+		}
+	}
+	slices.Sort(matches)
+	return score, slices.Compact(matches)
+}
+
+// rankFuzzy записывает в результат оценку и подсветку; при сортировке по score ключ —
+// оценка по убыванию, а при равной оценке выше тот, что ближе к корню
+func (h *searchHit) rankFuzzy(conds []fuzzyCond, n *model.FileInfo, depth int, sortBy string) {
+	h.res.Score, h.res.Matches = fuzzyRank(conds, n)
+	if sortBy == SearchSortScore {
+		h.num = int64(depth) - int64(h.res.Score)<<16
+	}
+}
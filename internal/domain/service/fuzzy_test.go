@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	cases := []struct {
+		pattern, s string
+		ok         bool
+		pos        string
+	}{
+		{"fbr", "foo_bar.go", true, "[0 4 6]"},
+		{"FBR", "foo_bar.go", true, "[0 4 6]"},
+		{"srchflt", "searchfilter.go", true, "[0 3 4 5 6 8 9]"},
+		{"sfilter", "searchfilter.go", true, "[0 6 7 8 9 10 11]"},
+		{"xyz", "foo_bar.go", false, ""},
+		{"rba", "foo_bar.go", false, ""},                // порядок важен, а шаблон короткий для опечатки
+		{"serch", "search.go", true, "[0 1 3 4 5]"},     // пропущенная буква — просто подпоследовательность
+		{"seaxrch", "search.go", true, "[0 1 2 3 4 5]"}, // лишний символ — опечатка
+		{"серв", "Сервис.go", true, "[0 1 2 3]"},
+	}
+	for _, c := range cases {
+		_, pos, ok := FuzzyMatch(c.pattern, c.s, false)
+		if ok != c.ok || ok && fmt.Sprint(pos) != c.pos {
+			t.Errorf("%q в %q: ok=%v %v, ожидалось ok=%v %s", c.pattern, c.s, ok, pos, c.ok, c.pos)
+		}
+	}
+	if _, _, ok := FuzzyMatch("FBR", "foo_bar.go", true); ok {
+		t.Error("с учётом регистра FBR не должен находиться")
+	}
+}
+
+func TestFuzzyMatch_Ranking(t *testing.T) {
+	score := func(p, s string) int {
+		sc, _, ok := FuzzyMatch(p, s, false)
+		if !ok {
+			t.Fatalf("%q должен находиться в %q", p, s)
+		}
+		return sc
+	}
+	better := [][3]string{
+		{"main", "main.go", "domain.go"},        // начало слова лучше середины
+		{"sf", "search_filter.go", "unsafe.go"}, // границы слов
+		{"sf", "searchFilter.go", "sofa.go"},    // camelCase
+		{"conf", "config.go", "c_o_n_f.go"},     // подряд лучше, чем с пропусками
+		{"search", "search.go", "seaxrch.go"},   // точное лучше опечатки
+	}
+	for _, b := range better {
+		if a, c := score(b[0], b[1]), score(b[0], b[2]); a <= c {
+			t.Errorf("%q: %q (%d) должен быть выше %q (%d)", b[0], b[1], a, b[2], c)
+		}
+	}
+}
+
+func TestSearchIn_Fuzzy(t *testing.T) {
+	node := func(dir, name string) model.FileInfo {
+		p := dir + "/" + name
+		return model.FileInfo{FullName: name, FullPath: p, FullPathOrig: p, FileType: "code"}
+	}
+	root := model.FileInfo{IsDir: true, FullName: "r", FullPath: "/r", FullPathOrig: "/r", Children: []model.FileInfo{
+		node("/r", "unsafe_config.go"),
+		node("/r", "README.md"),
+		{IsDir: true, FullName: "deep", FullPath: "/r/deep", FullPathOrig: "/r/deep", Children: []model.FileInfo{
+			node("/r/deep", "config.go"),
+		}},
+		node("/r", "config.go"),
+	}}
+	params := SearchParams{Query: "cfg", Match: MatchOptions{Mode: MatchFuzzy}, Recursive: true}
+
+	res, err := SearchIn(TreeSource(&root), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// одинаковые имена — ближе к корню выше; начало имени лучше середины
+	if got := fmt.Sprint(paths(res)); got != "[/r/config.go /r/deep/config.go /r/unsafe_config.go]" {
+		t.Errorf("порядок: %s", got)
+	}
+	if r := res.Results[0]; r.Score <= res.Results[2].Score || fmt.Sprint(r.Matches) != "[3 6 8]" {
+		t.Errorf("оценка и позиции в FullPathOrig: %+v", r)
+	}
+
+	params.Query = "cfg AND NOT path:deep"
+	if res, _ := SearchIn(TreeSource(&root), params); res.Total != 2 || res.Results[0].FullPathOrig != "/r/config.go" {
+		t.Errorf("NOT в режиме fuzzy: %v", paths(res))
+	}
+
+	params.Query, params.Sort = "cfg", SearchSortName
+	if res, _ := SearchIn(TreeSource(&root), params); res.Results[2].FullPathOrig != "/r/unsafe_config.go" || res.Results[2].Score == 0 {
+		t.Errorf("явная сортировка важнее оценки: %v", paths(res))
+	}
+}
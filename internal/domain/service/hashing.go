@@ -0,0 +1,105 @@
+package service
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"fsjson/internal/domain/model"
+)
+
+// HashAlgorithms — поддерживаемые алгоритмы хеширования содержимого
+var HashAlgorithms = []string{"md5", "sha1", "sha256", "sha512"}
+
+// NewHash создаёт хешер по имени алгоритма
+func NewHash(algo string) (hash.Hash, error) {
+	switch strings.ToLower(algo) {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("неизвестный алгоритм хеширования %q (поддерживаются: %s)",
+		algo, strings.Join(HashAlgorithms, ", "))
+}
+
+// ParseHashAlgorithms разбирает список алгоритмов через запятую
+func ParseHashAlgorithms(s string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, a := range strings.Split(s, ",") {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "" || seen[a] {
+			continue
+		}
+		if _, err := NewHash(a); err != nil {
+			return nil, err
+		}
+		seen[a] = true
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// FileHashes считает несколько хешей файла за одно чтение
+func FileHashes(path string, algos []string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashers := make([]hash.Hash, len(algos))
+	writers := make([]io.Writer, len(algos))
+	for i, a := range algos {
+		h, err := NewHash(a)
+		if err != nil {
+			return nil, err
+		}
+		hashers[i] = h
+		writers[i] = h
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(algos))
+	for i, a := range algos {
+		out[a] = hex.EncodeToString(hashers[i].Sum(nil))
+	}
+	return out, nil
+}
+
+// NodeHash возвращает сохранённый в снимке хеш узла по алгоритму
+// (md5 хранится в Md5, остальные — в Hashes)
+func NodeHash(n *model.FileInfo, algo string) string {
+	if algo == "" || algo == "md5" {
+		return n.Md5
+	}
+	return n.Hashes[algo]
+}
+
+// SetNodeHashes раскладывает посчитанные хеши по полям узла
+func SetNodeHashes(n *model.FileInfo, sums map[string]string) {
+	for a, h := range sums {
+		if a == "md5" {
+			n.Md5 = h
+			continue
+		}
+		if n.Hashes == nil {
+			n.Hashes = make(map[string]string, len(sums))
+		}
+		n.Hashes[a] = h
+	}
+}
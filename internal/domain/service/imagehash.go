@@ -0,0 +1,227 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // регистрация декодера GIF
+	_ "image/jpeg" // регистрация декодера JPEG
+	_ "image/png"  // регистрация декодера PNG
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Алгоритмы перцептивного хеша изображений (64 бита)
+const (
+	ImageHashAverage    = "ahash" // яркость каждой клетки 8×8 относительно средней
+	ImageHashDifference = "dhash" // перепады яркости между соседними клетками 9×8
+	ImageHashPerceptual = "phash" // знаки низких частот DCT картинки 32×32
+)
+
+// MaxImagePixels — изображения большей площади не декодируются: маленький файл может
+// объявить огромный холст (decompression bomb), и декодер займёт под него всю память
+const MaxImagePixels = 100_000_000
+
+// ErrImageTooLarge — изображение больше MaxImagePixels, хеш не считается
+var ErrImageTooLarge = errors.New("изображение слишком большое для перцептивного хеша")
+
+// ImageHashAlgorithms — поддерживаемые алгоритмы
+var ImageHashAlgorithms = []string{ImageHashAverage, ImageHashDifference, ImageHashPerceptual}
+
+// imageHashExts — форматы, для которых есть стандартные декодеры
+var imageHashExts = map[string]bool{"jpg": true, "jpeg": true, "png": true, "gif": true}
+
+// ParseImageHashAlgorithm проверяет название алгоритма ("" — хеш не считается)
+func ParseImageHashAlgorithm(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return "", nil
+	}
+	for _, a := range ImageHashAlgorithms {
+		if s == a {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("неизвестный алгоритм хеша изображений %q (поддерживаются: %s)",
+		s, strings.Join(ImageHashAlgorithms, ", "))
+}
+
+// CanImageHash — можно ли посчитать перцептивный хеш файла с таким расширением
+func CanImageHash(ext string) bool {
+	return imageHashExts[strings.TrimPrefix(strings.ToLower(ext), ".")]
+}
+
+// ImageFileHash декодирует изображение и возвращает хеш в виде "<алгоритм>:<16 hex>".
+// Размер сначала читается из заголовка: больше MaxImagePixels — ErrImageTooLarge.
+func ImageFileHash(path, algo string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return "", err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return "", fmt.Errorf("%w: %d×%d", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return "", err
+	}
+	h, err := ImageHash(img, algo)
+	if err != nil {
+		return "", err
+	}
+	return FormatImageHash(algo, h), nil
+}
+
+// ImageHash считает 64-битный перцептивный хеш изображения
+func ImageHash(img image.Image, algo string) (uint64, error) {
+	switch algo {
+	case ImageHashAverage:
+		px := grayThumbnail(img, 8, 8)
+		var mean float64
+		for _, v := range px {
+			mean += v
+		}
+		mean /= float64(len(px))
+		return bitsAbove(px, mean), nil
+	case ImageHashDifference:
+		px := grayThumbnail(img, 9, 8)
+		var h uint64
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				h <<= 1
+				if px[y*9+x] > px[y*9+x+1] {
+					h |= 1
+				}
+			}
+		}
+		return h, nil
+	case ImageHashPerceptual:
+		low := dctLowFrequencies(grayThumbnail(img, 32, 32), 32, 8)
+		ac := append([]float64(nil), low[1:]...) // без постоянной составляющей
+		sort.Float64s(ac)
+		return bitsAbove(low, median(ac)), nil
+	}
+	return 0, fmt.Errorf("неизвестный алгоритм хеша изображений %q", algo)
+}
+
+// median — медиана отсортированного среза; у pHash коэффициентов 63, середина одна
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// FormatImageHash — текстовое представление хеша для снимка
+func FormatImageHash(algo string, h uint64) string {
+	return fmt.Sprintf("%s:%016x", algo, h)
+}
+
+// ParseImageHash разбирает значение, записанное FormatImageHash
+func ParseImageHash(s string) (algo string, h uint64, err error) {
+	algo, hexPart, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("некорректный хеш изображения %q", s)
+	}
+	h, err = strconv.ParseUint(hexPart, 16, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("некорректный хеш изображения %q: %w", s, err)
+	}
+	return algo, h, nil
+}
+
+// HammingDistance — число различающихся битов
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func bitsAbove(px []float64, threshold float64) uint64 {
+	var h uint64
+	for _, v := range px {
+		h <<= 1
+		if v > threshold {
+			h |= 1
+		}
+	}
+	return h
+}
+
+// grayThumbnail уменьшает изображение до w×h усреднением яркости по площади
+func grayThumbnail(img image.Image, w, h int) []float64 {
+	b := img.Bounds()
+	sum := make([]float64, w*h)
+	cnt := make([]float64, w*h)
+	bw, bh := b.Dx(), b.Dy()
+	if bw == 0 || bh == 0 {
+		return sum
+	}
+	ycc, isYCbCr := img.(*image.YCbCr)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := (y - b.Min.Y) * h / bh * w
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var lum float64
+			if isYCbCr { // JPEG: яркость уже есть в плоскости Y
+				lum = float64(ycc.Y[ycc.YOffset(x, y)])
+			} else {
+				r, g, bl, _ := img.At(x, y).RGBA()
+				lum = (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)) / 257
+			}
+			i := row + (x-b.Min.X)*w/bw
+			sum[i] += lum
+			cnt[i]++
+		}
+	}
+	for i := range sum {
+		if cnt[i] > 0 {
+			sum[i] /= cnt[i]
+		}
+	}
+	return sum
+}
+
+// dctLowFrequencies — коэффициенты двумерного DCT-II квадрата n×n,
+// левый верхний угол k×k (построчно)
+func dctLowFrequencies(px []float64, n, k int) []float64 {
+	cos := make([]float64, k*n)
+	for u := 0; u < k; u++ {
+		for x := 0; x < n; x++ {
+			cos[u*n+x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / float64(2*n))
+		}
+	}
+	// сначала по строкам, затем по столбцам
+	rows := make([]float64, n*k)
+	for y := 0; y < n; y++ {
+		for u := 0; u < k; u++ {
+			var s float64
+			for x := 0; x < n; x++ {
+				s += px[y*n+x] * cos[u*n+x]
+			}
+			rows[y*k+u] = s
+		}
+	}
+	out := make([]float64, k*k)
+	for v := 0; v < k; v++ {
+		for u := 0; u < k; u++ {
+			var s float64
+			for y := 0; y < n; y++ {
+				s += rows[y*k+u] * cos[v*n+y]
+			}
+			out[v*k+u] = s
+		}
+	}
+	return out
+}
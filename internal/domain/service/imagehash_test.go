@@ -0,0 +1,181 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+// pattern — гладкая картинка с деталями разного масштаба (как у фотографии,
+// у которой энергия есть во многих низких частотах)
+func pattern(w, h int, invert bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			v := 0.5 + 0.2*math.Sin(2*math.Pi*fx) + 0.15*math.Cos(3*math.Pi*fy) +
+				0.1*math.Sin(5*math.Pi*(fx+fy)) + 0.05*math.Cos(7*math.Pi*fx*fy)
+			if invert {
+				v = 1 - v
+			}
+			c := uint8(255 * v)
+			img.Set(x, y, color.RGBA{c, c, c, 255})
+		}
+	}
+	return img
+}
+
+func TestImageHashNearDuplicates(t *testing.T) {
+	orig := pattern(256, 192, false)
+	small := pattern(64, 48, false)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, orig, &jpeg.Options{Quality: 40}); err != nil {
+		t.Fatal(err)
+	}
+	reencoded, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := pattern(256, 192, true)
+
+	for _, algo := range ImageHashAlgorithms {
+		h0, _ := ImageHash(orig, algo)
+		h1, _ := ImageHash(small, algo)
+		h2, _ := ImageHash(reencoded, algo)
+		h3, _ := ImageHash(other, algo)
+		if d := HammingDistance(h0, h1); d > 6 {
+			t.Errorf("%s: уменьшенная копия далеко: %d", algo, d)
+		}
+		if d := HammingDistance(h0, h2); d > 6 {
+			t.Errorf("%s: перекодированная копия далеко: %d", algo, d)
+		}
+		if d := HammingDistance(h0, h3); d < 20 {
+			t.Errorf("%s: другая картинка слишком близко: %d", algo, d)
+		}
+	}
+	if _, err := ImageHash(orig, "nope"); err == nil {
+		t.Error("ожидалась ошибка для неизвестного алгоритма")
+	}
+}
+
+func TestImageHashPerceptualMedian(t *testing.T) {
+	if m := median([]float64{1, 2, 3}); m != 2 {
+		t.Errorf("медиана нечётного числа: %v", m)
+	}
+	if m := median([]float64{1, 2, 3, 4}); m != 2.5 {
+		t.Errorf("медиана чётного числа: %v", m)
+	}
+
+	// 63 AC-коэффициента: выше медианы ровно 31 (старший бит — постоянная составляющая)
+	h, err := ImageHash(pattern(256, 192, false), ImageHashPerceptual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ac := bits.OnesCount64(h &^ (1 << 63)); ac != 31 {
+		t.Errorf("выше медианы %d AC-коэффициентов из 63, ожидался 31", ac)
+	}
+	if want := uint64(0xeae0c78c9db8a1aa); h != want {
+		t.Errorf("pHash = %016x, ожидался %016x", h, want)
+	}
+}
+
+func TestImageFileHash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.png")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, pattern(40, 30, false)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := ImageFileHash(path, ImageHashDifference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	algo, h, err := ParseImageHash(s)
+	if err != nil || algo != ImageHashDifference {
+		t.Fatalf("ParseImageHash(%q) = %s, %v", s, algo, err)
+	}
+	want, _ := ImageHash(pattern(40, 30, false), ImageHashDifference)
+	if h != want {
+		t.Errorf("хеш файла %x, ожидался %x", h, want)
+	}
+
+	// заголовок обещает 50000×50000: до декодирования пикселей дело доходить не должно
+	bomb := filepath.Join(dir, "bomb.png")
+	data := append([]byte(nil), buf.Bytes()...)
+	binary.BigEndian.PutUint32(data[16:], 50000) // IHDR: ширина, высота, затем CRC
+	binary.BigEndian.PutUint32(data[20:], 50000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	os.WriteFile(bomb, data, 0o644)
+	if _, err := ImageFileHash(bomb, ImageHashDifference); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("ожидалась ErrImageTooLarge, получено %v", err)
+	}
+
+	broken := filepath.Join(dir, "b.png")
+	os.WriteFile(broken, []byte("not a png"), 0o644)
+	if _, err := ImageFileHash(broken, ImageHashDifference); err == nil {
+		t.Error("ожидалась ошибка декодирования")
+	}
+	if _, err := ParseImageHashAlgorithm("PHash"); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParseImageHashAlgorithm("md5"); err == nil {
+		t.Error("ожидалась ошибка для md5")
+	}
+}
+
+func TestFindSimilarImages(t *testing.T) {
+	img := func(path string, size int64, hash string) model.FileInfo {
+		return model.FileInfo{FullPath: path, FullPathOrig: path, SizeBytes: size, ImageHash: hash}
+	}
+	root := model.FileInfo{IsDir: true, FullPath: "/r", Children: []model.FileInfo{
+		img("/r/a.jpg", 3000, "dhash:00000000000000ff"),
+		img("/r/a_small.jpg", 500, "dhash:00000000000000fe"), // 1 бит от a
+		img("/r/a_tiny.jpg", 100, "dhash:00000000000000fc"),  // 1 бит от a_small, 2 от a
+		img("/r/b.jpg", 2000, "dhash:ffffffff00000000"),
+		img("/r/c.png", 700, "phash:00000000000000ff"), // другой алгоритм — не сравнивается с a
+		img("/r/d.png", 600, "phash:00000000000000ff"),
+		img("/r/bad.png", 10, "garbage"),
+		{FullPath: "/r/notes.txt", SizeBytes: 5},
+	}}
+
+	res, err := FindSimilarImagesIn(TreeSource(&root), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Hashed != 6 {
+		t.Errorf("Hashed = %d, ожидалось 6", res.Hashed)
+	}
+	if len(res.Clusters) != 2 {
+		t.Fatalf("кластеров %d, ожидалось 2: %+v", len(res.Clusters), res.Clusters)
+	}
+	c := res.Clusters[0]
+	if c.Algo != ImageHashDifference || len(c.Images) != 3 {
+		t.Fatalf("первый кластер: %+v", c)
+	}
+	if c.Images[0].Path != "/r/a.jpg" || c.Images[2].Distance != 2 {
+		t.Errorf("порядок или расстояния: %+v", c.Images)
+	}
+	if res.Clusters[1].Algo != ImageHashPerceptual || res.Clusters[1].Images[0].Path != "/r/c.png" {
+		t.Errorf("второй кластер: %+v", res.Clusters[1])
+	}
+
+	res, _ = FindSimilarImagesIn(TreeSource(&root), 0)
+	if len(res.Clusters) != 1 {
+		t.Errorf("при расстоянии 0 ожидался только кластер точных совпадений: %+v", res.Clusters)
+	}
+}
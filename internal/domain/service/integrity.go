@@ -0,0 +1,111 @@
+package service
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"fsjson/internal/domain/model"
+)
+
+// Виды нарушений целостности
+const (
+	ViolationModified = "modified"
+	ViolationAdded    = "added"
+	ViolationRemoved  = "removed"
+)
+
+// FieldChange — изменившееся поле файла
+type FieldChange struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// IntegrityViolation — отклонение файла от эталонного снимка
+type IntegrityViolation struct {
+	Path    string        `json:"path"` // путь относительно корня
+	Kind    string        `json:"kind"`
+	IsDir   bool          `json:"is_dir"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// IntegrityReport — результат проверки по эталону
+type IntegrityReport struct {
+	Baseline   string               `json:"baseline"`
+	Root       string               `json:"root"`
+	HashAlgo   string               `json:"hash_algo"`
+	Checked    int                  `json:"checked"`
+	Violations []IntegrityViolation `json:"violations"`
+}
+
+// IndexByRelPath строит индекс узлов по пути относительно root
+// (корень получает ключ ".")
+func IndexByRelPath(nodes []model.FileInfo, root string) map[string]*model.FileInfo {
+	idx := make(map[string]*model.FileInfo, len(nodes))
+	for i := range nodes {
+		rel, err := filepath.Rel(root, nodes[i].FullPath)
+		if err != nil {
+			rel = nodes[i].FullPath
+		}
+		idx[rel] = &nodes[i]
+	}
+	return idx
+}
+
+// CompareToBaseline сравнивает текущее состояние с эталоном: содержимое (по хешу algo),
+// размер, права и владельца. Размер и хеш директорий не сравниваются —
+// они производные от содержимого.
+func CompareToBaseline(baseline, current map[string]*model.FileInfo, algo string) []IntegrityViolation {
+	var out []IntegrityViolation
+
+	for path, want := range baseline {
+		got, ok := current[path]
+		if !ok {
+			out = append(out, IntegrityViolation{Path: path, Kind: ViolationRemoved, IsDir: want.IsDir})
+			continue
+		}
+
+		var changes []FieldChange
+		diff := func(field, expected, actual string) {
+			if expected != actual {
+				changes = append(changes, FieldChange{Field: field, Expected: expected, Actual: actual})
+			}
+		}
+		if want.IsDir != got.IsDir {
+			diff("IsDir", strconv.FormatBool(want.IsDir), strconv.FormatBool(got.IsDir))
+		}
+		if !want.IsDir && !got.IsDir {
+			diff("SizeBytes", strconv.FormatInt(want.SizeBytes, 10), strconv.FormatInt(got.SizeBytes, 10))
+			if h := NodeHash(want, algo); h != "" {
+				diff(hashField(algo), h, NodeHash(got, algo))
+			}
+		}
+		diff("Perm", want.Perm, got.Perm)
+		if want.Owner != "" {
+			diff("Owner", want.Owner, got.Owner)
+		}
+		if want.Group != "" {
+			diff("Group", want.Group, got.Group)
+		}
+		if len(changes) > 0 {
+			out = append(out, IntegrityViolation{Path: path, Kind: ViolationModified, IsDir: got.IsDir, Changes: changes})
+		}
+	}
+
+	for path, got := range current {
+		if _, ok := baseline[path]; !ok {
+			out = append(out, IntegrityViolation{Path: path, Kind: ViolationAdded, IsDir: got.IsDir})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+func hashField(algo string) string {
+	if algo == "" || algo == "md5" {
+		return "Md5"
+	}
+	return "Hashes." + algo
+}
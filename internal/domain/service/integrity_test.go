@@ -0,0 +1,42 @@
+package service
+
+import (
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func TestCompareToBaseline(t *testing.T) {
+	baseline := []model.FileInfo{
+		{IsDir: true, FullPath: "/data", Perm: "drwxr-xr-x"},
+		{FullPath: "/data/same.txt", SizeBytes: 1, Md5: "aa", Perm: "-rw-r--r--", Owner: "root"},
+		{FullPath: "/data/changed.txt", SizeBytes: 1, Md5: "bb", Perm: "-rw-r--r--", Owner: "root"},
+		{FullPath: "/data/gone.txt", SizeBytes: 1, Md5: "cc"},
+	}
+	// тот же корень, но смонтированный в другом месте
+	current := []model.FileInfo{
+		{IsDir: true, FullPath: "/mnt/data", Perm: "drwxr-xr-x"},
+		{FullPath: "/mnt/data/same.txt", SizeBytes: 1, Md5: "aa", Perm: "-rw-r--r--", Owner: "root"},
+		{FullPath: "/mnt/data/changed.txt", SizeBytes: 1, Md5: "b2", Perm: "-rw-r--r--", Owner: "nobody"},
+		{FullPath: "/mnt/data/new.txt", SizeBytes: 1, Md5: "dd"},
+	}
+
+	got := CompareToBaseline(IndexByRelPath(baseline, "/data"), IndexByRelPath(current, "/mnt/data"), "md5")
+	if len(got) != 3 {
+		t.Fatalf("ожидалось 3 нарушения, получено %+v", got)
+	}
+
+	want := []struct{ path, kind string }{
+		{"changed.txt", ViolationModified},
+		{"gone.txt", ViolationRemoved},
+		{"new.txt", ViolationAdded},
+	}
+	for i, w := range want {
+		if got[i].Path != w.path || got[i].Kind != w.kind {
+			t.Fatalf("нарушение %d: ожидалось %s %s, получено %+v", i, w.kind, w.path, got[i])
+		}
+	}
+	if fields := got[0].Changes; len(fields) != 2 || fields[0].Field != "Md5" || fields[1].Field != "Owner" {
+		t.Fatalf("ожидались изменения Md5 и Owner, получено %+v", fields)
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Форматы файлов контрольных сумм
+const (
+	ManifestGNU = "gnu" // md5sum/sha256sum: "<hash>  <path>"
+	ManifestBSD = "bsd" // тегированный (--tag): "SHA256 (<path>) = <hash>"
+)
+
+// ManifestEntry — одна строка файла контрольных сумм
+type ManifestEntry struct {
+	Algo string // пусто для GNU-формата (алгоритм определяется по длине хеша)
+	Hash string
+	Path string
+}
+
+// FormatManifestLine форматирует строку так же, как это делают md5sum/sha256sum.
+// Пути с переводом строки или обратным слешем экранируются, а строка
+// начинается с "\" — как в GNU coreutils.
+func FormatManifestLine(format, algo, hash, path string) string {
+	escaped := strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(path)
+	prefix := ""
+	if escaped != path {
+		prefix = "\\"
+	}
+	if format == ManifestBSD {
+		return fmt.Sprintf("%s%s (%s) = %s", prefix, strings.ToUpper(algo), escaped, hash)
+	}
+	return fmt.Sprintf("%s%s  %s", prefix, hash, escaped)
+}
+
+// ParseManifestLine разбирает строку в GNU или BSD формате
+func ParseManifestLine(line string) (ManifestEntry, error) {
+	line = strings.TrimRight(line, "\r")
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+
+	var e ManifestEntry
+	if i := strings.Index(line, " ("); i > 0 && !strings.ContainsAny(line[:i], " \t") {
+		// BSD: ALGO (path) = hash
+		j := strings.LastIndex(line, ") = ")
+		if j < i {
+			return e, fmt.Errorf("некорректная строка BSD-формата: %q", line)
+		}
+		e.Algo = strings.ToLower(strings.ReplaceAll(line[:i], "-", ""))
+		e.Path = line[i+2 : j]
+		e.Hash = strings.ToLower(line[j+4:])
+	} else {
+		// GNU: hash␠␠path или hash␠*path (бинарный режим)
+		i := strings.IndexByte(line, ' ')
+		if i <= 0 || i+1 >= len(line) {
+			return e, fmt.Errorf("некорректная строка: %q", line)
+		}
+		e.Hash = strings.ToLower(line[:i])
+		e.Path = line[i+2:]
+		if line[i+1] != ' ' && line[i+1] != '*' {
+			return e, fmt.Errorf("некорректная строка: %q", line)
+		}
+	}
+	if escaped {
+		e.Path = unescapeManifestPath(e.Path)
+	}
+	if e.Algo == "" {
+		e.Algo = HashAlgoByLength(len(e.Hash))
+	}
+	return e, nil
+}
+
+// HashAlgoByLength угадывает алгоритм по длине hex-строки хеша
+func HashAlgoByLength(n int) string {
+	switch n {
+	case 32:
+		return "md5"
+	case 40:
+		return "sha1"
+	case 64:
+		return "sha256"
+	case 128:
+		return "sha512"
+	}
+	return ""
+}
+
+func unescapeManifestPath(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
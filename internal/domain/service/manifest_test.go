@@ -0,0 +1,33 @@
+package service
+
+import "testing"
+
+func TestManifestLineRoundTrip(t *testing.T) {
+	cases := []struct {
+		format, algo, hash, path string
+	}{
+		{ManifestGNU, "md5", "764efa883dda1e11db47671c4a3bbd9e", "dir/file name (1).txt"},
+		{ManifestBSD, "sha1", "0123456789abcdef0123456789abcdef01234567", "/abs/path.bin"},
+		{ManifestGNU, "md5", "764efa883dda1e11db47671c4a3bbd9e", "weird\\name\nwith newline"},
+	}
+	for _, c := range cases {
+		line := FormatManifestLine(c.format, c.algo, c.hash, c.path)
+		e, err := ParseManifestLine(line)
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		if e.Algo != c.algo || e.Hash != c.hash || e.Path != c.path {
+			t.Fatalf("%q: получено %+v", line, e)
+		}
+	}
+}
+
+func TestParseManifestLine_BinaryMode(t *testing.T) {
+	e, err := ParseManifestLine("764efa883dda1e11db47671c4a3bbd9e *photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Path != "photo.jpg" || e.Algo != "md5" {
+		t.Fatalf("получено %+v", e)
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"fsjson/internal/domain/model"
+)
+
+// Режимы сопоставления шаблонов в поиске
+const (
+	MatchWildcard = "wildcard" // * и ?, поиск подстроки (по умолчанию)
+	MatchLiteral  = "literal"  // подстрока как есть, без спецсимволов
+	MatchRegex    = "regex"    // регулярное выражение Go (RE2), поиск подстроки
+	MatchGlob     = "glob"     // glob на весь путь или имя: *, ?, [abc], ** — любое число каталогов
+	MatchFuzzy    = "fuzzy"    // символы по порядку, как в fzf; результаты ранжируются (см. FuzzyMatch)
+)
+
+// MatchOptions — как сопоставлять шаблоны имени
+type MatchOptions struct {
+	Mode          string
+	CaseSensitive bool
+	FullPath      bool // сопоставлять с полным путём, а не с именем
+}
+
+// Matcher — скомпилированный шаблон
+type Matcher func(s string) bool
+
+// ParseMatchMode проверяет режим ("" — wildcard)
+func ParseMatchMode(s string) (string, error) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "":
+		return MatchWildcard, nil
+	case MatchWildcard, MatchLiteral, MatchRegex, MatchGlob, MatchFuzzy:
+		return s, nil
+	}
+	return "", fmt.Errorf("неизвестный режим поиска %q (поддерживаются: %s, %s, %s, %s, %s)",
+		s, MatchWildcard, MatchLiteral, MatchRegex, MatchGlob, MatchFuzzy)
+}
+
+// target — строка узла, с которой сравнивается шаблон имени.
+// Glob со слешем всегда сравнивается с путём, как в .gitignore.
+func (o MatchOptions) target(n *model.FileInfo, pattern string) string {
+	if o.FullPath || o.Mode == MatchGlob && strings.Contains(pattern, "/") {
+		return n.FullPath
+	}
+	return n.FullName
+}
+
+// CompileMatcher компилирует шаблон; ошибка — некорректное регулярное выражение или glob
+func CompileMatcher(pattern string, o MatchOptions) (Matcher, error) {
+	switch o.Mode {
+	case MatchFuzzy:
+		return func(s string) bool { _, _, ok := FuzzyMatch(pattern, s, o.CaseSensitive); return ok }, nil
+	case MatchLiteral:
+		if o.CaseSensitive {
+			return func(s string) bool { return strings.Contains(s, pattern) }, nil
+		}
+		lower := strings.ToLower(pattern)
+		return func(s string) bool { return strings.Contains(strings.ToLower(s), lower) }, nil
+	}
+
+	var expr string
+	switch o.Mode {
+	case MatchRegex:
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("некорректное регулярное выражение %q: %v", pattern, err)
+		}
+		expr = pattern
+	case MatchGlob:
+		var err error
+		if expr, err = globToRegex(pattern); err != nil {
+			return nil, err
+		}
+	default:
+		expr = wildcardToRegex(pattern)
+	}
+	if !o.CaseSensitive {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный шаблон %q: %v", pattern, err)
+	}
+	return re.MatchString, nil
+}
+
+// wildcardToRegex — * и ? в регулярное выражение, остальные символы экранируются
+func wildcardToRegex(q string) string {
+	var b strings.Builder
+	for _, r := range q {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// globToRegex — glob в регулярное выражение на всю строку:
+// * и ? не переходят через '/', ** — любое число каталогов, [...] — класс символов.
+// Шаблон без ведущего '/' может начинаться в любом каталоге.
+func globToRegex(g string) (string, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.HasPrefix(g, "/") && !strings.HasPrefix(g, "**") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(g); i++ {
+		switch c := g[i]; c {
+		case '*':
+			if i+1 < len(g) && g[i+1] == '*' {
+				i++
+				if i+1 < len(g) && g[i+1] == '/' { // **/ — ноль и более каталогов
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(g[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("некорректный glob %q: не закрыта '['", g)
+			}
+			class := g[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			if class == "" || class == "^" {
+				return "", fmt.Errorf("некорректный glob %q: пустой класс символов", g)
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(g[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return b.String(), nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func TestCompileMatcher(t *testing.T) {
+	cases := []struct {
+		pattern string
+		opts    MatchOptions
+		s       string
+		want    bool
+	}{
+		// wildcard: спецсимволы регулярных выражений — обычные символы
+		{"a+b(1)", MatchOptions{}, "A+B(1).txt", true},
+		{"a+b", MatchOptions{}, "aab", false},
+		{"[draft]", MatchOptions{}, "report [draft].doc", true},
+		{"*.jpg", MatchOptions{}, "x.JPG", true},
+		{"*.jpg", MatchOptions{CaseSensitive: true}, "x.JPG", false},
+		{"r?port", MatchOptions{}, "report", true},
+		// literal: * и ? тоже обычные символы
+		{"*.txt", MatchOptions{Mode: MatchLiteral}, "a.txt", false},
+		{"*.txt", MatchOptions{Mode: MatchLiteral}, "what*.txt", true},
+		{"Read", MatchOptions{Mode: MatchLiteral, CaseSensitive: true}, "readme", false},
+		// regex
+		{`^img_\d{4}\.jpe?g$`, MatchOptions{Mode: MatchRegex}, "IMG_0042.jpeg", true},
+		{`^img_\d{4}\.jpe?g$`, MatchOptions{Mode: MatchRegex, CaseSensitive: true}, "IMG_0042.jpeg", false},
+		{`(foo|bar)+`, MatchOptions{Mode: MatchRegex}, "xbarfoo", true},
+		// glob
+		{"*.go", MatchOptions{Mode: MatchGlob}, "main.go", true},
+		{"*.go", MatchOptions{Mode: MatchGlob}, "main.go.bak", false},
+		{"src/**/*.go", MatchOptions{Mode: MatchGlob}, "/home/u/src/a/b/c.go", true},
+		{"src/**/*.go", MatchOptions{Mode: MatchGlob}, "/home/u/src/c.go", true},
+		{"src/*.go", MatchOptions{Mode: MatchGlob}, "/home/u/src/a/c.go", false},
+		{"/home/*/src/**", MatchOptions{Mode: MatchGlob}, "/home/u/src/a/c.go", true},
+		{"/home/*/src/**", MatchOptions{Mode: MatchGlob}, "/opt/home/u/src/c.go", false},
+		{"**/.git/**", MatchOptions{Mode: MatchGlob}, "/r/.git/objects/ab", true},
+		{"img[0-9].png", MatchOptions{Mode: MatchGlob}, "img7.png", true},
+		{"img[!0-9].png", MatchOptions{Mode: MatchGlob}, "img7.png", false},
+		{"файл?.txt", MatchOptions{Mode: MatchGlob}, "Файл1.txt", true},
+	}
+	for _, c := range cases {
+		m, err := CompileMatcher(c.pattern, c.opts)
+		if err != nil {
+			t.Errorf("%q (%+v): %v", c.pattern, c.opts, err)
+			continue
+		}
+		if got := m(c.s); got != c.want {
+			t.Errorf("%q (%+v) на %q: получено %v", c.pattern, c.opts, c.s, got)
+		}
+	}
+
+	for _, bad := range []struct {
+		pattern string
+		mode    string
+	}{{"(a", MatchRegex}, {"a[", MatchRegex}, {"img[0-9.png", MatchGlob}, {"a[]", MatchGlob}} {
+		if _, err := CompileMatcher(bad.pattern, MatchOptions{Mode: bad.mode}); err == nil {
+			t.Errorf("%q (%s): ожидалась ошибка", bad.pattern, bad.mode)
+		}
+	}
+	if _, err := ParseMatchMode("fuzzy-ish"); err == nil {
+		t.Error("ожидалась ошибка для неизвестного режима")
+	}
+}
+
+func TestSearchIn_MatchModes(t *testing.T) {
+	file := func(path string) model.FileInfo {
+		return model.FileInfo{FullName: path[len("/r/src/"):], FullPath: path, FullPathOrig: path}
+	}
+	root := model.FileInfo{IsDir: true, FullName: "r", FullPath: "/r", Children: []model.FileInfo{
+		{IsDir: true, FullName: "src", FullPath: "/r/src", Children: []model.FileInfo{
+			file("/r/src/main.go"),
+			file("/r/src/Main_test.go"),
+			file("/r/src/notes (copy).txt"),
+		}},
+	}}
+	search := func(query string, m MatchOptions) []string {
+		t.Helper()
+		res, err := SearchIn(TreeSource(&root), SearchParams{Query: query, Match: m, Recursive: true})
+		if err != nil {
+			t.Fatalf("%q: %v", query, err)
+		}
+		var out []string
+		for _, r := range res.Results {
+			out = append(out, r.FullPathOrig)
+		}
+		return out
+	}
+
+	if got := search(`"notes (copy)"`, MatchOptions{}); len(got) != 1 {
+		t.Errorf("шаблон со скобками и пробелом в кавычках: %v", got)
+	}
+	if got := search("*(copy).txt", MatchOptions{}); len(got) != 1 {
+		t.Errorf("скобки внутри слова — часть шаблона: %v", got)
+	}
+	if got := search("notes(copy)", MatchOptions{Mode: MatchLiteral}); len(got) != 0 {
+		t.Errorf("literal не должен находить без пробела: %v", got)
+	}
+	if got := search(`^main`, MatchOptions{Mode: MatchRegex}); len(got) != 2 {
+		t.Errorf("regex без учёта регистра: %v", got)
+	}
+	if got := search(`^main`, MatchOptions{Mode: MatchRegex, CaseSensitive: true}); len(got) != 1 {
+		t.Errorf("regex с учётом регистра: %v", got)
+	}
+	if got := search("src", MatchOptions{FullPath: true}); len(got) != 4 {
+		t.Errorf("по полному пути src есть у каталога и трёх файлов: %v", got)
+	}
+	if got := search("/r/**/*_test.go", MatchOptions{Mode: MatchGlob}); len(got) != 1 || got[0] != "/r/src/Main_test.go" {
+		t.Errorf("glob по пути: %v", got)
+	}
+	if got := search("path:^/r/src/m", MatchOptions{Mode: MatchRegex}); len(got) != 2 {
+		t.Errorf("path: в режиме regex: %v", got)
+	}
+
+	_, err := SearchIn(TreeSource(&root), SearchParams{Query: "(a", Match: MatchOptions{Mode: MatchRegex}})
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Errorf("некорректный шаблон — ошибка разбора запроса, а не паника: %v", err)
+	}
+	_, err = SearchIn(TreeSource(&root), SearchParams{Query: `"a("`, Match: MatchOptions{Mode: MatchRegex}})
+	if !errors.As(err, &qe) {
+		t.Errorf("некорректное регулярное выражение: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"fsjson/internal/domain/model"
+)
+
+// ComputeMerkleHashes проставляет директориям хеш содержимого (Merkle):
+// хеш метки "d" и отсортированного списка "тип, хеш, имя" дочерних элементов.
+// Одинаковые деревья получают одинаковый хеш независимо от расположения и дат,
+// поэтому их можно находить и сравнивать между снимками, не спускаясь внутрь.
+// Хеш считается для каждого алгоритма из algos и хранится там же, где хеш файлов
+// (md5 — в Md5, остальные — в Hashes). Если хеш хотя бы одного потомка неизвестен,
+// хеш директории остаётся пустым.
+func ComputeMerkleHashes(node *model.FileInfo, algos []string) {
+	if !node.IsDir {
+		return
+	}
+	for i := range node.Children {
+		ComputeMerkleHashes(&node.Children[i], algos)
+	}
+	for _, algo := range algos {
+		SetNodeHashes(node, map[string]string{algo: merkleHash(node, algo)})
+	}
+}
+
+func merkleHash(node *model.FileInfo, algo string) string {
+	type entry struct{ kind, hash, name string }
+	entries := make([]entry, 0, len(node.Children))
+	for i := range node.Children {
+		c := &node.Children[i]
+		h := NodeHash(c, algo)
+		if h == "" {
+			return ""
+		}
+		kind := "f"
+		if c.IsDir {
+			kind = "d"
+		}
+		entries = append(entries, entry{kind, h, c.FullName})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].name != entries[j].name {
+			return entries[i].name < entries[j].name
+		}
+		return entries[i].hash < entries[j].hash
+	})
+
+	h, err := NewHash(algo)
+	if err != nil {
+		return ""
+	}
+	// метка типа: иначе пустая директория получила бы хеш пустого файла
+	h.Write([]byte("d\x00"))
+	for _, e := range entries {
+		// имя последним и с \x00 в конце: в именах файлов нулевой байт невозможен
+		fmt.Fprintf(h, "%s %s %s\x00", e.kind, e.hash, e.name)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// TreeHashAlgorithms — алгоритмы, хеши которых есть у файлов дерева
+// (md5 первым, остальные по алфавиту)
+func TreeHashAlgorithms(root *model.FileInfo) []string {
+	seen := make(map[string]bool)
+	walkTree(root, 0, func(n *model.FileInfo, _ int) error {
+		if n.IsDir {
+			return nil
+		}
+		if n.Md5 != "" {
+			seen["md5"] = true
+		}
+		for a := range n.Hashes {
+			seen[a] = true
+		}
+		return nil
+	})
+	var out []string
+	if seen["md5"] {
+		out = append(out, "md5")
+		delete(seen, "md5")
+	}
+	rest := make([]string, 0, len(seen))
+	for a := range seen {
+		rest = append(rest, a)
+	}
+	sort.Strings(rest)
+	return append(out, rest...)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func merkleTestDir(base string, bHash string) model.FileInfo {
+	return model.FileInfo{
+		IsDir: true, FullName: "src", FullPath: base,
+		Children: []model.FileInfo{
+			{FullName: "b.txt", FullPath: base + "/b.txt", Md5: bHash},
+			{
+				IsDir: true, FullName: "lib", FullPath: base + "/lib",
+				Children: []model.FileInfo{{FullName: "a.go", FullPath: base + "/lib/a.go", Md5: "aa"}},
+			},
+		},
+	}
+}
+
+func TestComputeMerkleHashes(t *testing.T) {
+	a := merkleTestDir("/one/src", "bb")
+	b := merkleTestDir("/two/src", "bb")
+	// порядок детей не влияет на хеш
+	b.Children[0], b.Children[1] = b.Children[1], b.Children[0]
+	c := merkleTestDir("/three/src", "cc")
+
+	for _, n := range []*model.FileInfo{&a, &b, &c} {
+		ComputeMerkleHashes(n, []string{"md5"})
+	}
+	if a.Md5 == "" || a.Md5 != b.Md5 {
+		t.Fatalf("одинаковые деревья должны иметь одинаковый хеш: %q vs %q", a.Md5, b.Md5)
+	}
+	if a.Md5 == c.Md5 {
+		t.Fatal("изменение файла должно менять хеш директории")
+	}
+	if a.Children[1].Md5 != c.Children[1].Md5 {
+		t.Fatal("неизменённая поддиректория должна сохранить хеш")
+	}
+
+	// другое имя файла — другое дерево
+	d := merkleTestDir("/four/src", "bb")
+	d.Children[0].FullName = "c.txt"
+	ComputeMerkleHashes(&d, []string{"md5"})
+	if d.Md5 == a.Md5 {
+		t.Fatal("переименование файла должно менять хеш директории")
+	}
+
+	// хеш потомка неизвестен — хеш директории тоже
+	e := merkleTestDir("/five/src", "")
+	ComputeMerkleHashes(&e, []string{"md5"})
+	if e.Md5 != "" || e.Children[1].Md5 == "" {
+		t.Fatalf("ожидался пустой хеш корня и непустой у lib: %q, %q", e.Md5, e.Children[1].Md5)
+	}
+}
+
+func TestComputeMerkleHashes_EmptyDir(t *testing.T) {
+	dir := model.FileInfo{IsDir: true, FullName: "empty"}
+	ComputeMerkleHashes(&dir, []string{"md5"})
+	if dir.Md5 == "" || dir.Md5 == Md5String("") {
+		t.Fatalf("пустая директория не должна совпадать с пустым файлом: %q", dir.Md5)
+	}
+}
+
+func TestTreeHashAlgorithms(t *testing.T) {
+	root := merkleTestDir("/src", "bb")
+	root.Children[1].Children[0].Hashes = map[string]string{"sha256": "x", "sha1": "y"}
+	got := TreeHashAlgorithms(&root)
+	if len(got) != 3 || got[0] != "md5" || got[1] != "sha1" || got[2] != "sha256" {
+		t.Fatalf("получено %v", got)
+	}
+}
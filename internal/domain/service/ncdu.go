@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fsjson/internal/domain/model"
+)
+
+// Версия формата дампа ncdu (ncdu -o / ncdu -f), которую мы пишем
+const (
+	ncduMajorVersion = 1
+	ncduMinorVersion = 2
+)
+
+// ncduEntry — элемент дампа ncdu. Директория в дампе — массив: [ncduEntry, потомки...].
+// dsize (занято на диске) в снимке нет, поэтому пишется asize.
+type ncduEntry struct {
+	Name   string `json:"name"`
+	Asize  int64  `json:"asize,omitempty"`
+	Dsize  int64  `json:"dsize,omitempty"`
+	Mtime  int64  `json:"mtime,omitempty"`
+	Mode   uint32 `json:"mode,omitempty"`
+	NotReg bool   `json:"notreg,omitempty"` // не обычный файл: ссылка, устройство, сокет
+}
+
+// NcduCounts — сколько элементов записано в дамп
+type NcduCounts struct {
+	Files, Dirs int
+}
+
+// WriteNcdu пишет снимок в формате дампа ncdu (JSON, версия 1.2): ncdu -f файл.
+// Обход потоковый — в памяти только стек открытых директорий. Источник должен
+// отдавать одно дерево; у директорий SizeBytes — суммарный, ncdu считает его сам.
+func WriteNcdu(out io.Writer, src NodeSource, progver string, now time.Time) (NcduCounts, error) {
+	var counts NcduCounts
+	w := bufio.NewWriter(out)
+	meta, _ := json.Marshal(map[string]any{"progname": "fsjson", "progver": progver, "timestamp": now.Unix()})
+	fmt.Fprintf(w, "[%d,%d,%s", ncduMajorVersion, ncduMinorVersion, meta)
+
+	open := 0 // открытые массивы директорий
+	roots := 0
+	err := src(func(n *model.FileInfo, depth int) error {
+		if depth == 0 {
+			if roots++; roots > 1 {
+				return fmt.Errorf("ncdu: в снимке несколько корней, а дамп хранит одно дерево")
+			}
+		}
+		for ; open > depth; open-- {
+			w.WriteString("]")
+		}
+		name := n.FullName
+		if depth == 0 || name == "" {
+			name = n.FullPathOrig // корень — полный путь, как у ncdu
+			if depth > 0 {
+				name = filepath.Base(name)
+			}
+		}
+		data, err := json.Marshal(ncduEntryOf(n, name))
+		if err != nil {
+			return err
+		}
+		w.WriteString(",\n")
+		if n.IsDir {
+			counts.Dirs++
+			w.WriteString("[")
+			open++
+		} else {
+			counts.Files++
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return counts, err
+	}
+	for ; open > 0; open-- {
+		w.WriteString("]")
+	}
+	w.WriteString("]\n")
+	return counts, w.Flush()
+}
+
+// ncduEntryOf — элемент дампа для узла; mode — с битами типа, как st_mode
+func ncduEntryOf(n *model.FileInfo, name string) ncduEntry {
+	e := ncduEntry{Name: name}
+	if !n.IsDir {
+		e.Asize, e.Dsize = n.SizeBytes, n.SizeBytes
+	}
+	if !n.Updated.IsZero() {
+		e.Mtime = n.Updated.Unix()
+	}
+	if bits, ok := permBits(n.Perm); ok {
+		switch {
+		case n.IsDir:
+			e.Mode = bits | 0o040000
+		case strings.ContainsAny(n.Perm[:len(n.Perm)-9], "LDpSc?"): // буквы типа из os.FileMode.String
+			e.NotReg = true
+		default:
+			e.Mode = bits | 0o100000
+		}
+	}
+	return e
+}
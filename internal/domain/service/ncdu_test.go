@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"fsjson/internal/domain/model"
+)
+
+func TestWriteNcdu(t *testing.T) {
+	root := statsTree()
+	root.Perm = "drwxr-xr-x"
+	root.Children[0].Children[0].Perm = "-rw-r--r--"
+	root.Children[1].Children[0].Perm = "Lrwxrwxrwx"
+
+	var buf bytes.Buffer
+	counts, err := WriteNcdu(&buf, TreeSource(&root), "1.0", time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts != (NcduCounts{Files: 4, Dirs: 4}) {
+		t.Errorf("записано: %+v", counts)
+	}
+
+	var dump []json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatalf("дамп — не JSON: %v\n%s", err, buf.String())
+	}
+	if len(dump) != 4 || string(dump[0]) != "1" || string(dump[1]) != "2" ||
+		!strings.Contains(string(dump[2]), `"timestamp":1700000000`) {
+		t.Fatalf("заголовок дампа: %s", buf.String())
+	}
+
+	// директория — массив [свойства, потомки…]; имена потомков — без пути
+	var names func(v any) string
+	names = func(v any) string {
+		switch v := v.(type) {
+		case []any:
+			parts := []string{}
+			for _, c := range v {
+				parts = append(parts, names(c))
+			}
+			return "[" + strings.Join(parts, " ") + "]"
+		case map[string]any:
+			return v["name"].(string)
+		}
+		return "?"
+	}
+	var tree any
+	json.Unmarshal(dump[3], &tree)
+	if got := names(tree); got != "[/r [a x.mp4 [deep x.mkv]] [b x.txt] x.mp4]" {
+		t.Errorf("дерево: %s", got)
+	}
+
+	file := tree.([]any)[1].([]any)[1].(map[string]any)
+	link := tree.([]any)[2].([]any)[1].(map[string]any)
+	if file["asize"] != 3000.0 || file["dsize"] != 3000.0 || file["mode"] != float64(0o100644) {
+		t.Errorf("файл: %v", file)
+	}
+	if link["notreg"] != true || link["mode"] != nil {
+		t.Errorf("ссылка: %v", link)
+	}
+}
+
+func TestWriteNcdu_SeveralRoots(t *testing.T) {
+	a := model.FileInfo{FullPathOrig: "/a"}
+	b := model.FileInfo{FullPathOrig: "/b"}
+	src := func(visit NodeVisitor) error {
+		for _, n := range []*model.FileInfo{&a, &b} {
+			if err := visit(n, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if _, err := WriteNcdu(&bytes.Buffer{}, src, "", time.Now()); err == nil {
+		t.Error("несколько корней должны быть ошибкой")
+	}
+}
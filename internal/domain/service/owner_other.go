@@ -0,0 +1,10 @@
+//go:build !unix
+
+package service
+
+import "os"
+
+// FileOwner — на платформах без uid/gid владелец не определяется
+func FileOwner(info os.FileInfo) (string, string) {
+	return "", ""
+}
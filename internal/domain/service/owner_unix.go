@@ -0,0 +1,56 @@
+//go:build unix
+
+package service
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+var (
+	userNames  sync.Map // uid -> имя
+	groupNames sync.Map // gid -> имя
+)
+
+// FileOwner возвращает владельца и группу файла (имена, либо числовые id,
+// если имя не найдено)
+func FileOwner(info os.FileInfo) (string, string) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+	uid := strconv.FormatUint(uint64(st.Uid), 10)
+	gid := strconv.FormatUint(uint64(st.Gid), 10)
+	return lookupName(&userNames, uid, lookupUser), lookupName(&groupNames, gid, lookupGroup)
+}
+
+func lookupUser(uid string) (string, error) {
+	u, err := user.LookupId(uid)
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+func lookupGroup(gid string) (string, error) {
+	g, err := user.LookupGroupId(gid)
+	if err != nil {
+		return "", err
+	}
+	return g.Name, nil
+}
+
+func lookupName(cache *sync.Map, id string, lookup func(string) (string, error)) string {
+	if v, ok := cache.Load(id); ok {
+		return v.(string)
+	}
+	name, err := lookup(id)
+	if err != nil || name == "" {
+		name = id
+	}
+	cache.Store(id, name)
+	return name
+}
@@ -0,0 +1,536 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fsjson/internal/domain/model"
+)
+
+// Query — разобранное выражение поиска.
+//
+// Грамматика:
+//
+//	выражение := и { "OR" и }
+//	и         := не { ["AND"] не }          — соседние условия по умолчанию объединяются через AND
+//	не        := "NOT" не | первичное
+//	первичное := "(" выражение ")" | поле оператор значения | шаблон
+//	значения  := значение | "(" значение { "|" значение } ")"
+//
+// Шаблон без поля ищется в имени (как раньше: * и ?, без учёта регистра);
+// режим, регистр и сравнение с полным путём задаются MatchOptions.
+// Поля: name, path, ext, type, is (dir|file), size, depth, modified, created, content.
+// Операторы: ":" "=" "!=" и для size, depth, modified, created ещё "<" "<=" ">" ">=".
+// Для modified и created значение — возраст (30d, 6mo, 1y): modified<30d — изменён меньше
+// 30 дней назад; либо момент или период (см. ParseTimeExpr): modified:today, created>=2025-01.
+// content ищет слова в индексе содержимого (см. ContentSearcher.Find): content:"open file";
+// строки каждого слова в файле учитываются только первые model.ContentIndexMaxLines.
+// В режиме fuzzy name и path сопоставляются нечётко (см. FuzzyMatch), а по условиям вне NOT
+// считается оценка совпадения для ранжирования.
+type Query struct {
+	root     queryNode
+	contents []*contentCond // условия content:, заполняются из индекса перед поиском
+	fuzzy    []fuzzyCond    // условия name и path вне NOT в режиме fuzzy — по ним ранжируются результаты
+}
+
+// QueryError — ошибка разбора запроса с позицией (в символах, с единицы)
+type QueryError struct {
+	Query string
+	Pos   int
+	Msg   string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("ошибка в запросе %q, позиция %d: %s", e.Query, e.Pos, e.Msg)
+}
+
+// ParseQuery разбирает выражение поиска; пустая строка — запрос без условий
+func ParseQuery(s string) (*Query, error) {
+	return parseQueryAt(s, time.Now(), MatchOptions{}, nil)
+}
+
+// ParseQueryWith — ParseQuery с заданным режимом сопоставления шаблонов name и path
+// и часовым поясом для дат без зоны и периодов вроде today (nil — местное время)
+func ParseQueryWith(s string, opts MatchOptions, loc *time.Location) (*Query, error) {
+	return parseQueryAt(s, time.Now(), opts, loc)
+}
+
+// parseQueryAt — разбор с заданным «сейчас» для условий на возраст
+func parseQueryAt(s string, now time.Time, opts MatchOptions, loc *time.Location) (*Query, error) {
+	p := &queryParser{src: s, now: now, opts: opts, loc: loc}
+	if p.skipSpace(); p.eof() {
+		return &Query{}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); !p.eof() {
+		if p.peek() == ')' {
+			return nil, p.errorf("лишняя ')'")
+		}
+		return nil, p.errorf("неожиданное %q", p.src[p.pos:])
+	}
+	return &Query{root: root, contents: p.contents, fuzzy: p.fuzzy}, nil
+}
+
+// Match — подходит ли узел под выражение (пустой запрос подходит всем)
+func (q *Query) Match(n *model.FileInfo, depth int) bool {
+	return q == nil || q.root == nil || q.root.match(n, depth)
+}
+
+// String — выражение в полностью расставленных скобках (для отладки и тестов)
+func (q *Query) String() string {
+	if q == nil || q.root == nil {
+		return ""
+	}
+	return q.root.String()
+}
+
+// queryNode — узел AST
+type queryNode interface {
+	match(n *model.FileInfo, depth int) bool
+	String() string
+}
+
+type andNode struct{ left, right queryNode }
+
+func (a andNode) match(n *model.FileInfo, d int) bool {
+	return a.left.match(n, d) && a.right.match(n, d)
+}
+func (a andNode) String() string { return "(" + a.left.String() + " AND " + a.right.String() + ")" }
+
+type orNode struct{ left, right queryNode }
+
+func (o orNode) match(n *model.FileInfo, d int) bool {
+	return o.left.match(n, d) || o.right.match(n, d)
+}
+func (o orNode) String() string { return "(" + o.left.String() + " OR " + o.right.String() + ")" }
+
+type notNode struct{ x queryNode }
+
+func (o notNode) match(n *model.FileInfo, d int) bool { return !o.x.match(n, d) }
+func (o notNode) String() string                      { return "NOT " + o.x.String() }
+
+// fieldNode — условие на поле; совпадает, если подходит хотя бы одно из значений
+type fieldNode struct {
+	field, op string
+	values    []string
+	test      func(n *model.FileInfo, depth int) bool
+}
+
+func (f fieldNode) match(n *model.FileInfo, d int) bool { return f.test(n, d) }
+func (f fieldNode) String() string {
+	return f.field + f.op + strings.Join(f.values, "|")
+}
+
+// queryFields — поля и допустимые для них операторы
+var queryFields = map[string]string{
+	"name": "eq", "path": "eq", "ext": "eq", "type": "eq", "is": "eq",
+	"size": "cmp", "depth": "cmp", "modified": "cmp", "created": "cmp",
+	"content": "eq",
+}
+
+type queryParser struct {
+	src  string
+	pos  int
+	now  time.Time
+	loc  *time.Location
+	opts MatchOptions
+
+	contents []*contentCond
+	fuzzy    []fuzzyCond
+	negated  int // глубина вложенности NOT
+}
+
+func (p *queryParser) eof() bool  { return p.pos >= len(p.src) }
+func (p *queryParser) peek() byte { return p.src[p.pos] }
+
+func (p *queryParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\n' || p.peek() == '\r') {
+		p.pos++
+	}
+}
+
+func (p *queryParser) errorf(format string, args ...any) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+func (p *queryParser) errorAt(pos int, format string, args ...any) error {
+	return &QueryError{Query: p.src, Pos: utf8.RuneCountInString(p.src[:pos]) + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// keyword съедает служебное слово, если оно стоит отдельно
+func (p *queryParser) keyword(kw string) bool {
+	p.skipSpace()
+	if !strings.HasPrefix(p.src[p.pos:], kw) {
+		return false
+	}
+	end := p.pos + len(kw)
+	if end < len(p.src) && !isQueryBreak(p.src[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func isQueryBreak(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')'
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.skipSpace(); p.eof() || p.peek() == ')' {
+			return left, nil
+		}
+		save := p.pos
+		if p.keyword("OR") {
+			p.pos = save
+			return left, nil
+		}
+		p.keyword("AND")
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.keyword("NOT") {
+		p.negated++
+		x, err := p.parseNot()
+		p.negated--
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("неожиданный конец запроса")
+	}
+	switch p.peek() {
+	case '(':
+		open := p.pos
+		p.pos++
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpace(); p.eof() || p.peek() != ')' {
+			return nil, p.errorAt(open, "не закрыта скобка")
+		}
+		p.pos++
+		return x, nil
+	case ')':
+		return nil, p.errorf("лишняя ')'")
+	}
+	for _, kw := range []string{"AND", "OR"} {
+		if save := p.pos; p.keyword(kw) {
+			p.pos = save
+			return nil, p.errorf("ожидалось условие перед %s", kw)
+		}
+	}
+	return p.parseTerm()
+}
+
+// parseTerm — условие на поле или шаблон имени
+func (p *queryParser) parseTerm() (queryNode, error) {
+	start := p.pos
+	i := p.pos
+	for i < len(p.src) && (p.src[i] >= 'a' && p.src[i] <= 'z' || p.src[i] >= 'A' && p.src[i] <= 'Z') {
+		i++
+	}
+	field := strings.ToLower(p.src[p.pos:i])
+	if _, ok := queryFields[field]; ok { // у известного поля оператор можно отделить пробелами: size > 1GB
+		for i < len(p.src) && p.src[i] == ' ' {
+			i++
+		}
+	}
+	if op := queryOperator(p.src[i:]); field != "" && op != "" {
+		if _, ok := queryFields[field]; !ok {
+			return nil, p.errorf("неизвестное поле %q (поддерживаются: name, path, ext, type, is, size, depth, modified, created, content)", field)
+		}
+		p.pos = i + len(op)
+		values, err := p.parseValues(field + op)
+		if err != nil {
+			return nil, err
+		}
+		return p.buildField(start, field, op, values)
+	}
+
+	value, err := p.parseValue("", false)
+	if err != nil {
+		return nil, err
+	}
+	return p.buildField(start, "name", ":", []string{value})
+}
+
+func queryOperator(s string) string {
+	for _, op := range []string{">=", "<=", "!=", ":", "=", ">", "<"} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// parseValues — одно значение или группа (a|b|c)
+func (p *queryParser) parseValues(after string) ([]string, error) {
+	p.skipSpace()
+	if p.eof() || p.peek() != '(' {
+		v, err := p.parseValue(after, false)
+		if err != nil {
+			return nil, err
+		}
+		return []string{v}, nil
+	}
+	open := p.pos
+	p.pos++
+	var values []string
+	for {
+		p.skipSpace()
+		v, err := p.parseValue(after, true)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if p.skipSpace(); p.eof() {
+			return nil, p.errorAt(open, "не закрыта скобка")
+		}
+		switch p.peek() {
+		case '|':
+			p.pos++
+		case ')':
+			p.pos++
+			return values, nil
+		default:
+			return nil, p.errorf("ожидалось '|' или ')'")
+		}
+	}
+}
+
+// parseValue — слово до пробела либо строка в кавычках (внутри неё \" и \\ — экранирование,
+// остальные \ сохраняются для регулярных выражений). Скобки внутри слова входят в него,
+// если сбалансированы: file(1).txt, ^IMG_(\d+)$. В группе значений слово кончается на '|'.
+func (p *queryParser) parseValue(after string, inGroup bool) (string, error) {
+	if !p.eof() && p.peek() == '"' {
+		open := p.pos
+		var b strings.Builder
+		for p.pos++; !p.eof(); p.pos++ {
+			switch c := p.peek(); {
+			case c == '"':
+				p.pos++
+				return b.String(), nil
+			case c == '\\' && p.pos+1 < len(p.src) && (p.src[p.pos+1] == '"' || p.src[p.pos+1] == '\\'):
+				p.pos++
+				b.WriteByte(p.peek())
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", p.errorAt(open, "не закрыта кавычка")
+	}
+	start := p.pos
+	depth := 0
+	for ; !p.eof(); p.pos++ {
+		c := p.peek()
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || inGroup && depth == 0 && c == '|' {
+			break
+		}
+		if c == '(' {
+			if p.pos == start {
+				break
+			}
+			depth++
+		}
+		if c == ')' {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+	}
+	if p.pos == start {
+		if after != "" {
+			return "", p.errorf("ожидалось значение после %s", after)
+		}
+		return "", p.errorf("ожидалось условие")
+	}
+	return p.src[start:p.pos], nil
+}
+
+// buildField проверяет оператор и значения и строит условие
+func (p *queryParser) buildField(pos int, field, op string, values []string) (queryNode, error) {
+	node := fieldNode{field: field, op: op, values: values}
+	if queryFields[field] == "eq" && op != ":" && op != "=" && op != "!=" {
+		return nil, p.errorAt(pos, "оператор %s не применим к полю %s", op, field)
+	}
+
+	var tests []func(n *model.FileInfo, depth int) bool
+	for _, v := range values {
+		t, err := p.valueTest(field, op, v)
+		if err != nil {
+			return nil, p.errorAt(pos, "%s: %v", field, err)
+		}
+		tests = append(tests, t)
+	}
+	matchAny := func(n *model.FileInfo, depth int) bool {
+		for _, t := range tests {
+			if t(n, depth) {
+				return true
+			}
+		}
+		return false
+	}
+	node.test = matchAny
+	if op == "!=" {
+		node.test = func(n *model.FileInfo, depth int) bool { return !matchAny(n, depth) }
+	}
+	return node, nil
+}
+
+// valueTest — проверка поля на одно значение (для != — без отрицания)
+func (p *queryParser) valueTest(field, op, v string) (func(n *model.FileInfo, depth int) bool, error) {
+	if p.opts.Mode == MatchFuzzy && p.negated == 0 && op != "!=" && (field == "name" || field == "path") {
+		p.fuzzy = append(p.fuzzy, fuzzyCond{pattern: v, path: field == "path", opts: p.opts})
+	}
+	if op == "!=" {
+		op = "="
+	}
+	switch field {
+	case "name":
+		m, err := CompileMatcher(v, p.opts)
+		if err != nil {
+			return nil, err
+		}
+		return func(n *model.FileInfo, _ int) bool { return m(p.opts.target(n, v)) }, nil
+	case "path":
+		m, err := p.pathMatcher(v)
+		if err != nil {
+			return nil, err
+		}
+		return func(n *model.FileInfo, _ int) bool { return m(n.FullPath) }, nil
+	case "ext":
+		ext := strings.TrimPrefix(v, ".")
+		return func(n *model.FileInfo, _ int) bool {
+			return !n.IsDir && strings.EqualFold(strings.TrimPrefix(n.Ext, "."), ext)
+		}, nil
+	case "type":
+		return func(n *model.FileInfo, _ int) bool { return strings.EqualFold(n.FileType, v) }, nil
+	case "content":
+		c := &contentCond{expr: v}
+		p.contents = append(p.contents, c)
+		return func(n *model.FileInfo, _ int) bool { return c.match(n) }, nil
+	case "is":
+		switch strings.ToLower(v) {
+		case "dir":
+			return func(n *model.FileInfo, _ int) bool { return n.IsDir }, nil
+		case "file":
+			return func(n *model.FileInfo, _ int) bool { return !n.IsDir }, nil
+		}
+		return nil, fmt.Errorf("ожидалось dir или file, получено %q", v)
+	case "size":
+		size, err := ParseSize(v)
+		if err != nil {
+			return nil, err
+		}
+		return func(n *model.FileInfo, _ int) bool { return compareOp(op, n.SizeBytes, size) }, nil
+	case "depth":
+		d, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("ожидалось целое число, получено %q", v)
+		}
+		return func(_ *model.FileInfo, depth int) bool { return compareOp(op, depth, d) }, nil
+	case "modified", "created":
+		get := func(n *model.FileInfo) time.Time { return n.Updated }
+		if field == "created" {
+			get = func(n *model.FileInfo) time.Time { return n.Created }
+		}
+		if age, err := ParseAge(v); err == nil {
+			if op == ":" || op == "=" {
+				return nil, fmt.Errorf("для возраста используйте < или >, например %s<%s", field, v)
+			}
+			now := p.now
+			return func(n *model.FileInfo, _ int) bool { return compareOp(op, now.Sub(get(n)), age) }, nil
+		}
+		from, to, err := ParseTimeExpr(v, p.now, p.loc)
+		if err != nil {
+			return nil, fmt.Errorf("ожидалась дата (2025-01-31), возраст (30d) или период (today, last-month), получено %q", v)
+		}
+		if op == ":" || op == "=" {
+			if to.IsZero() {
+				return nil, fmt.Errorf("%q — момент времени, используйте < или >", v)
+			}
+			return func(n *model.FileInfo, _ int) bool { ts := get(n); return !ts.Before(from) && ts.Before(to) }, nil
+		}
+		bound := TimeBound(op, from, to).UnixNano()
+		return func(n *model.FileInfo, _ int) bool { return compareOp(op, get(n).UnixNano(), bound) }, nil
+	}
+	return nil, fmt.Errorf("неизвестное поле")
+}
+
+func compareOp[T cmp.Ordered](op string, a, b T) bool {
+	switch op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return a == b
+}
+
+// pathMatcher — условие path: начало пути, а шаблон (* и ? в режиме wildcard,
+// регулярное выражение или glob в своих режимах) — на весь путь; fuzzy — по всему пути
+func (p *queryParser) pathMatcher(v string) (Matcher, error) {
+	opts := p.opts
+	opts.FullPath = true
+	switch {
+	case opts.Mode == MatchRegex || opts.Mode == MatchGlob || opts.Mode == MatchFuzzy:
+		return CompileMatcher(v, opts)
+	case opts.Mode != MatchLiteral && strings.ContainsAny(v, "*?"):
+		expr := "^" + wildcardToRegex(v) + "$"
+		if !opts.CaseSensitive {
+			expr = "(?i)" + expr
+		}
+		return regexp.MustCompile(expr).MatchString, nil // экранировано wildcardToRegex — всегда корректно
+	case opts.CaseSensitive:
+		return func(s string) bool { return strings.HasPrefix(s, v) }, nil
+	}
+	prefix := strings.ToLower(v)
+	return func(s string) bool { return strings.HasPrefix(strings.ToLower(s), prefix) }, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fsjson/internal/domain/model"
+)
+
+func TestParseQuery_AST(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	cases := map[string]string{
+		"":                     "",
+		"*.mp4":                "name:*.mp4",
+		"report budget":        "(name:report AND name:budget)",
+		`"my report"`:          "name:my report",
+		"a OR b c":             "(name:a OR (name:b AND name:c))",
+		"(a OR b) AND c":       "((name:a OR name:b) AND name:c)",
+		"NOT NOT a":            "NOT NOT name:a",
+		"ext:(mp4|mkv)":        "ext:mp4|mkv",
+		"ext:( mp4 | mkv )":    "ext:mp4|mkv",
+		"Size >= 1.5GB":        "size>=1.5GB",
+		"size > 1GB is:file":   "(size>1GB AND is:file)",
+		"type!=video depth<=2": "(type!=video AND depth<=2)",
+		"NOT path:/tmp/ OR x":  "(NOT path:/tmp/ OR name:x)",
+		"ORDER":                "name:ORDER",
+		"ext:(mp4|mkv) AND size>1GB AND modified<30d AND NOT path:/tmp/": "(((ext:mp4|mkv AND size>1GB) AND modified<30d) AND NOT path:/tmp/)",
+	}
+	for in, want := range cases {
+		q, err := parseQueryAt(in, now, MatchOptions{}, time.UTC)
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if got := q.String(); got != want {
+			t.Errorf("%q: получено %q, ожидалось %q", in, got, want)
+		}
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	cases := map[string]struct {
+		pos int
+		msg string
+	}{
+		"(a OR b":        {1, "не закрыта скобка"},
+		"a)":             {2, "лишняя ')'"},
+		"a AND":          {6, "неожиданный конец"},
+		"OR a":           {1, "ожидалось условие перед OR"},
+		"color:red":      {1, "неизвестное поле"},
+		"size>big":       {1, "некорректный размер"},
+		"size>":          {6, "ожидалось значение после size>"},
+		"ext>mp4":        {1, "оператор > не применим"},
+		"ext:(mp4 mkv)":  {10, "ожидалось '|' или ')'"},
+		`"abc`:           {1, "не закрыта кавычка"},
+		"modified:30d":   {1, "используйте < или >"},
+		"is:link":        {1, "ожидалось dir или file"},
+		"файл AND (b OR": {15, "неожиданный конец"},
+	}
+	for in, want := range cases {
+		_, err := ParseQuery(in)
+		var qe *QueryError
+		if !errors.As(err, &qe) {
+			t.Errorf("%q: ожидалась QueryError, получено %v", in, err)
+			continue
+		}
+		if qe.Pos != want.pos || !strings.Contains(qe.Msg, want.msg) {
+			t.Errorf("%q: позиция %d, %q; ожидалось %d, %q", in, qe.Pos, qe.Msg, want.pos, want.msg)
+		}
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	node := func(path, ext, typ string, size int64, modified time.Time) model.FileInfo {
+		name := path[strings.LastIndex(path, "/")+1:]
+		return model.FileInfo{FullName: name, FullPath: path, FullPathOrig: path, Ext: ext,
+			FileType: typ, SizeBytes: size, Updated: modified, Created: modified}
+	}
+	movie := node("/media/Movie.MKV", "mkv", "video", 2<<30, now.Add(-10*24*time.Hour))
+	oldClip := node("/media/clip.mp4", "mp4", "video", 3<<30, now.AddDate(-2, 0, 0))
+	tmpClip := node("/tmp/clip.mp4", "mp4", "video", 3<<30, now.Add(-time.Hour))
+	notes := node("/media/notes.txt", "txt", "text", 100, now.Add(-time.Hour))
+	dir := model.FileInfo{IsDir: true, FullName: "media", FullPath: "/media", Updated: now}
+
+	cases := []struct {
+		query string
+		n     model.FileInfo
+		depth int
+		want  bool
+	}{
+		{"ext:(mp4|mkv) AND size>1GB AND modified<30d AND NOT path:/tmp/", movie, 1, true},
+		{"ext:(mp4|mkv) AND size>1GB AND modified<30d AND NOT path:/tmp/", oldClip, 1, false},
+		{"ext:(mp4|mkv) AND size>1GB AND modified<30d AND NOT path:/tmp/", tmpClip, 1, false},
+		{"ext:(mp4|mkv) AND size>1GB AND modified<30d AND NOT path:/tmp/", notes, 1, false},
+		{"movie", movie, 1, true}, // шаблон имени без учёта регистра, как раньше
+		{"*.mkv", movie, 1, true},
+		{"cl?p", oldClip, 1, true},
+		{"modified>1y", oldClip, 1, true},
+		{"modified<2025-01-01", oldClip, 1, true},
+		{"modified:2025-06-01", notes, 1, true},
+		{"modified:2025-05-31", notes, 1, false},
+		{"modified:today", notes, 1, true},
+		{"modified:yesterday", notes, 1, false},
+		{"modified>=this-month", movie, 1, false},
+		{"modified<last-year", oldClip, 1, true},
+		{"created>-2h", tmpClip, 1, true},
+		{"created>=2025-05-01T00:00", movie, 1, true},
+		{"size<=100 type:TEXT", notes, 1, true},
+		{"size:100", notes, 1, true},
+		{"ext!=(mp4|mkv)", notes, 1, true},
+		{"ext!=(mp4|mkv)", movie, 1, false},
+		{"ext:.mkv", movie, 1, true},
+		{"is:dir", dir, 0, true},
+		{"is:dir", notes, 1, false},
+		{"ext:mkv", dir, 0, false},
+		{"depth>=1", movie, 1, true},
+		{"depth>=1", dir, 0, false},
+		{"path:/media/*.txt", notes, 1, true},
+		{"path:/media/*.txt", tmpClip, 1, false},
+		{"notes OR size>1GB", oldClip, 1, true},
+		{"(notes OR movie) AND type:video", movie, 1, true},
+		{"(notes OR movie) AND type:video", notes, 1, false},
+	}
+	for _, c := range cases {
+		q, err := parseQueryAt(c.query, now, MatchOptions{}, time.UTC)
+		if err != nil {
+			t.Fatalf("%q: %v", c.query, err)
+		}
+		if got := q.Match(&c.n, c.depth); got != c.want {
+			t.Errorf("%q на %s: получено %v", c.query, c.n.FullPath, got)
+		}
+	}
+}
+
+func TestSearchIn_Query(t *testing.T) {
+	root := dupTree()
+	res, err := SearchIn(TreeSource(&root), SearchParams{Query: "ext:jpg AND NOT path:/r/backup", Recursive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results) != 2 || res.Results[0].FullPathOrig != "/r/photos/a.jpg" {
+		t.Errorf("результаты: %+v", res.Results)
+	}
+
+	_, err = SearchIn(TreeSource(&root), SearchParams{Query: "ext:(jpg", Recursive: true})
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Errorf("ожидалась ошибка разбора, получено %v", err)
+	}
+}
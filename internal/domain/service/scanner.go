@@ -121,9 +121,6 @@ func ComputeDirSizes(node *model.FileInfo) int64 {
 	if !latest.IsZero() {
 		node.Updated = latest
 	}
-	if node.Md5 == "" {
-		node.Md5 = Md5String(node.FullName)
-	}
 	return total
 }
 
@@ -160,14 +157,12 @@ func ProcessPathWith(
 		Perm:         info.Mode().String(),
 		FileType:     DetectFileType(info.Name()),
 	}
+	entry.Owner, entry.Group = FileOwner(info)
 
 	if info.IsDir() {
 		if readDirCount != nil {
 			entry.ChildCount = readDirCount(path)
 		}
-		if !skipMd5 {
-			entry.Md5 = Md5String(info.Name())
-		}
 	} else if !skipMd5 && fileMD5 != nil {
 		entry.Md5 = fileMD5(path)
 	}
@@ -1,25 +1,56 @@
 package service
 
 import (
+	"fmt"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
 	"fsjson/internal/domain/model"
 )
 
+// Сортировка результатов поиска ("" — в порядке обхода снимка)
+const (
+	SearchSortName     = "name"
+	SearchSortSize     = "size"
+	SearchSortModified = "modified"
+	SearchSortCreated  = "created"
+	SearchSortPath     = "path"
+	SearchSortDepth    = "depth"
+	SearchSortScore    = "score" // по качеству нечёткого совпадения (по умолчанию в режиме fuzzy)
+)
+
 // SearchParams — параметры фильтрации
 type SearchParams struct {
-	Query     string
+	Query     string       // выражение поиска (см. Query); простой шаблон ищется в имени
+	Match     MatchOptions // режим сопоставления шаблонов, регистр, имя или полный путь
 	Path      string
 	Types     []string
+	Exts      []string // расширения без точки
 	SizeCmp   map[string]int64
+	DepthCmp  map[string]int64 // глубина от корня: gt, gte, lt, lte, eq
 	Created   map[string]time.Time
 	Modified  map[string]time.Time
+	Perm      string            // права: 755, 0644 или шаблон строки Perm (-rw-r--r--, d*)
+	Owners    []string          // "user", ":group" или "user:group"
+	Hashed    string            // у файла есть хеш: алгоритм (md5, sha256, …), any или none
+	Hashes    map[string]string // точное значение хеша по алгоритму: md5=…, sha256=…
+	Like      string            // файлы с тем же содержимым, что и этот (путь в снимке или на диске)
+	Only      string            // "dir" или "file"
 	Recursive bool
 	Limit     int
 	Offset    int
+	Sort      string // см. SearchSort*
+	Desc      bool
+	Cursor    string         // NextCursor предыдущей страницы; вместо Offset
+	Location  *time.Location // пояс для дат без зоны и периодов вроде today (nil — местный)
+
+	// AllowDiskLike — образец like, которого нет в снимке, можно прочитать с диска.
+	// Только для CLI: по HTTP это позволило бы клиенту проверять и читать файлы сервера.
+	AllowDiskLike bool
+
+	Content      string                           // слова в содержимом, как content: в запросе
+	ContentIndex func() (*ContentSearcher, error) // загрузка индекса содержимого, если он понадобился
 }
 
 // SearchResult — один элемент результата
@@ -29,128 +60,198 @@ type SearchResult struct {
 	FileType     string    `json:"FileType"`
 	Modified     time.Time `json:"Modified"`
 	Created      time.Time `json:"Created"`
+
+	Snippets []ContentSnippet `json:"Snippets,omitempty"` // строки с найденным содержимым
+	Score    int              `json:"Score,omitempty"`    // оценка нечёткого совпадения (mode=fuzzy)
+	Matches  []int            `json:"Matches,omitempty"`  // позиции совпавших символов в FullPathOrig (в рунах)
 }
 
 // SearchStats — статистика по типам
 type SearchStats map[string]int
 
-// SearchResponse — итоговый ответ
+// SearchResponse — итоговый ответ.
+// Total и Stats считаются по всем совпадениям, Results — запрошенная страница.
 type SearchResponse struct {
-	Results []SearchResult `json:"results"`
-	Stats   SearchStats    `json:"stats"`
-	Total   int            `json:"total"`
+	Results    []SearchResult `json:"results"`
+	Stats      SearchStats    `json:"stats"`
+	Total      int            `json:"total"`
+	NextCursor string         `json:"next_cursor,omitempty"` // есть, если за страницей ещё есть результаты
+}
+
+// ParseSearchSort проверяет поле и направление сортировки (order: asc, desc или "")
+func ParseSearchSort(sort, order string) (string, bool, error) {
+	sort = strings.ToLower(strings.TrimSpace(sort))
+	switch sort {
+	case "", SearchSortName, SearchSortSize, SearchSortModified, SearchSortCreated, SearchSortPath, SearchSortDepth, SearchSortScore:
+	default:
+		return "", false, fmt.Errorf("неизвестная сортировка %q (поддерживаются: name, size, modified, created, path, depth, score)", sort)
+	}
+	switch strings.ToLower(strings.TrimSpace(order)) {
+	case "", "asc":
+		return sort, false, nil
+	case "desc":
+		return sort, true, nil
+	}
+	return "", false, fmt.Errorf("неизвестный порядок %q (asc или desc)", order)
 }
 
 // SearchFiles — основной алгоритм поиска
 func SearchFiles(root *model.FileInfo, params SearchParams) SearchResponse {
-	results := []SearchResult{}
-	var regex *regexp.Regexp
+	resp, _ := SearchIn(TreeSource(root), params)
+	return resp
+}
 
-	if params.Query != "" {
-		regex = wildcardToRegex(params.Query)
+// SearchIn — поиск по произвольному источнику узлов (дерево или потоковый JSON).
+// Обходятся все узлы, чтобы посчитать Total и Stats, но в памяти держится
+// только нужная страница: при сортировке — ограниченная куча из Offset+Limit элементов.
+// Ошибка разбора запроса возвращается как *QueryError.
+// В режиме fuzzy без явной сортировки результаты идут по оценке совпадения.
+func SearchIn(src NodeSource, params SearchParams) (SearchResponse, error) {
+	empty := SearchResponse{Results: []SearchResult{}, Stats: SearchStats{}}
+	if params.Match.Mode == MatchFuzzy && params.Sort == "" {
+		params.Sort = SearchSortScore
+	}
+	scope, err := compileSearch(src, params)
+	if err != nil {
+		return empty, err
+	}
+	var after *searchHit
+	if params.Cursor != "" {
+		if after, err = decodeSearchCursor(params.Cursor, params); err != nil {
+			return empty, err
+		}
 	}
 
-	startPath := strings.TrimSuffix(params.Path, string(filepath.Separator))
+	skip := max(params.Offset, 0)
+	if after != nil {
+		skip = 0
+	}
+	page := &hitHeap{desc: params.Desc}
+	need := -1
+	if params.Limit > 0 {
+		need = skip + params.Limit
+	}
+	stats := make(SearchStats)
+	total, eligible, seq := 0, 0, 0
 
-	typeSet := make(map[string]bool)
-	for _, t := range params.Types {
-		t = strings.ToLower(strings.TrimSpace(t))
-		if t != "" {
-			typeSet[t] = true
+	err = scope.walk(src, func(node *model.FileInfo, depth int) {
+		total++
+		stats[node.FileType]++
+		hit := newSearchHit(node, depth, seq, params.Sort)
+		if len(scope.query.fuzzy) > 0 {
+			hit.rankFuzzy(scope.query.fuzzy, node, depth, params.Sort)
 		}
+		seq++
+		if after == nil || page.compare(&hit, after) > 0 {
+			eligible++
+			page.push(hit, need)
+		}
+	})
+	if err != nil {
+		return empty, err
 	}
 
-	var walk func(node *model.FileInfo)
-	walk = func(node *model.FileInfo) {
-		if startPath != "" && !strings.HasPrefix(node.FullPath, startPath) {
-			return
+	hits := page.sorted()
+	results := []SearchResult{}
+	if skip < len(hits) {
+		hits = hits[skip:]
+		for _, h := range hits {
+			results = append(results, h.res)
 		}
+	} else {
+		hits = nil
+	}
 
-		if matchNode(node, params, regex, typeSet) {
-			results = append(results, SearchResult{
-				FullPathOrig: node.FullPathOrig,
-				SizeBytes:    node.SizeBytes,
-				FileType:     node.FileType,
-				Modified:     node.Updated,
-				Created:      node.Created,
-			})
-		}
+	if len(scope.contents) > 0 {
+		attachSnippets(results, scope.contents)
+	}
 
-		if node.IsDir && params.Recursive {
-			for i := range node.Children {
-				walk(&node.Children[i])
-			}
-		}
+	resp := SearchResponse{Results: results, Stats: stats, Total: total}
+	if len(hits) > 0 && skip+len(hits) < eligible {
+		resp.NextCursor = encodeSearchCursor(&hits[len(hits)-1], params)
 	}
+	return resp, nil
+}
 
-	walk(root)
+// searchScope — скомпилированные условия поиска: запрос, фильтры, like и содержимое.
+// Общая часть поиска и отчётов, которые считаются по найденным узлам (Aggregate).
+type searchScope struct {
+	params   SearchParams
+	query    *Query
+	filter   *nodeFilter
+	contents []*contentCond
+	start    string
+}
 
-	// пагинация
-	start := params.Offset
-	if start > len(results) {
-		return SearchResponse{Results: []SearchResult{}, Stats: SearchStats{}, Total: 0}
+// compileSearch разбирает запрос и фильтры; для like и content читает источник и индекс
+func compileSearch(src NodeSource, params SearchParams) (*searchScope, error) {
+	query, err := ParseQueryWith(params.Query, params.Match, params.Location)
+	if err != nil {
+		return nil, err
 	}
-	end := len(results)
-	if params.Limit > 0 && start+params.Limit < end {
-		end = start + params.Limit
+	filter, err := compileNodeFilter(params)
+	if err != nil {
+		return nil, err
 	}
-	results = results[start:end]
-
-	stats := make(SearchStats)
-	for _, r := range results {
-		stats[r.FileType]++
+	if params.Like != "" {
+		if filter.like, err = resolveLike(src, params.Like, params.AllowDiskLike); err != nil {
+			return nil, err
+		}
 	}
-
-	return SearchResponse{
-		Results: results,
-		Stats:   stats,
-		Total:   len(results),
+	contents := query.contents
+	if params.Content != "" {
+		filter.content = &contentCond{expr: params.Content}
+		contents = append(contents, filter.content)
 	}
+	if err := bindContent(contents, params.ContentIndex); err != nil {
+		return nil, err
+	}
+	return &searchScope{
+		params:   params,
+		query:    query,
+		filter:   filter,
+		contents: contents,
+		start:    strings.TrimSuffix(params.Path, string(filepath.Separator)),
+	}, nil
+}
+
+// walk обходит узлы под Path (без Recursive — только верхний уровень) и передаёт в found подходящие
+func (s *searchScope) walk(src NodeSource, found func(n *model.FileInfo, depth int)) error {
+	return src(func(node *model.FileInfo, depth int) error {
+		if s.start != "" && !strings.HasPrefix(node.FullPath, s.start) {
+			// предки начального пути не подходят сами, но в них надо зайти
+			if node.IsDir && strings.HasPrefix(s.start, node.FullPath) {
+				return nil
+			}
+			return ErrSkipChildren
+		}
+
+		if matchNode(node, depth, s.params, s.query, s.filter) {
+			found(node, depth)
+		}
+
+		if !s.params.Recursive {
+			return ErrSkipChildren
+		}
+		return nil
+	})
 }
 
 // matchNode — фильтрация узла по всем параметрам
-func matchNode(n *model.FileInfo, p SearchParams, re *regexp.Regexp, typeSet map[string]bool) bool {
+func matchNode(n *model.FileInfo, depth int, p SearchParams, q *Query, f *nodeFilter) bool {
 	// query
-	if re != nil && !re.MatchString(strings.ToLower(n.FullName)) {
+	if !q.Match(n, depth) {
 		return false
 	}
 
-	// type (множественный)
-	if len(typeSet) > 0 && !typeSet[strings.ToLower(n.FileType)] {
+	// type, ext, depth, perm, owner, hashed, only
+	if !f.match(n, depth, p) {
 		return false
 	}
 
 	// size (все операции включая between)
-	for op, val := range p.SizeCmp {
-		switch op {
-		case "gt":
-			if !(n.SizeBytes > val) {
-				return false
-			}
-		case "gte":
-			if !(n.SizeBytes >= val) {
-				return false
-			}
-		case "lt":
-			if !(n.SizeBytes < val) {
-				return false
-			}
-		case "lte":
-			if !(n.SizeBytes <= val) {
-				return false
-			}
-		case "eq":
-			if n.SizeBytes != val {
-				return false
-			}
-		case "between":
-			// диапазон задан как min,max
-			min := p.SizeCmp["between_min"]
-			max := p.SizeCmp["between_max"]
-			if !(n.SizeBytes >= min && n.SizeBytes <= max) {
-				return false
-			}
-		}
+	if !matchIntCmp(p.SizeCmp, n.SizeBytes) {
+		return false
 	}
 
 	// created
@@ -199,13 +300,3 @@ func matchNode(n *model.FileInfo, p SearchParams, re *regexp.Regexp, typeSet map
 
 	return true
 }
-
-// wildcardToRegex — поддержка шаблонов (*, ?)
-func wildcardToRegex(q string) *regexp.Regexp {
-	q = strings.ToLower(q)
-	q = strings.ReplaceAll(q, ".", "\\.")
-	q = strings.ReplaceAll(q, "*", ".*")
-	q = strings.ReplaceAll(q, "?", ".")
-	re := regexp.MustCompile(q)
-	return re
-}
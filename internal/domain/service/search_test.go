@@ -0,0 +1,257 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fsjson/internal/domain/model"
+)
+
+// searchTree — /r с 10 файлами f0..f9 (размер 10*(i%4), изменены i часов назад)
+// и подкаталогом /r/sub с двумя картинками
+func searchTree() model.FileInfo {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	root := model.FileInfo{IsDir: true, FullName: "r", FullPath: "/r", FullPathOrig: "/r"}
+	for i := range 10 {
+		p := fmt.Sprintf("/r/f%d.txt", i)
+		root.Children = append(root.Children, model.FileInfo{FullName: fmt.Sprintf("f%d.txt", i), FullPath: p, FullPathOrig: p,
+			FileType: "text", SizeBytes: int64(10 * (i % 4)), Updated: base.Add(-time.Duration(i) * time.Hour)})
+	}
+	root.Children = append(root.Children, model.FileInfo{IsDir: true, FullName: "sub", FullPath: "/r/sub", FullPathOrig: "/r/sub",
+		Children: []model.FileInfo{
+			{FullName: "B.jpg", FullPath: "/r/sub/B.jpg", FullPathOrig: "/r/sub/B.jpg", FileType: "image", SizeBytes: 5},
+			{FullName: "a.jpg", FullPath: "/r/sub/a.jpg", FullPathOrig: "/r/sub/a.jpg", FileType: "image", SizeBytes: 500},
+		}})
+	return root
+}
+
+func paths(res SearchResponse) []string {
+	out := []string{}
+	for _, r := range res.Results {
+		out = append(out, r.FullPathOrig)
+	}
+	return out
+}
+
+func TestSearchIn_TotalsAndStats(t *testing.T) {
+	root := searchTree()
+	res, err := SearchIn(TreeSource(&root), SearchParams{Query: "is:file", Recursive: true, Limit: 3, Offset: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 12 || len(res.Results) != 3 {
+		t.Fatalf("Total = %d, результатов %d; ожидалось 12 и 3", res.Total, len(res.Results))
+	}
+	if res.Stats["text"] != 10 || res.Stats["image"] != 2 {
+		t.Errorf("статистика должна считаться по всем совпадениям: %v", res.Stats)
+	}
+	if got := paths(res); got[0] != "/r/f2.txt" {
+		t.Errorf("без сортировки — порядок обхода: %v", got)
+	}
+
+	res, _ = SearchIn(TreeSource(&root), SearchParams{Query: "is:file", Recursive: true, Limit: 3, Offset: 50})
+	if res.Total != 12 || len(res.Results) != 0 || res.NextCursor != "" {
+		t.Errorf("смещение за концом: Total = %d, результатов %d", res.Total, len(res.Results))
+	}
+}
+
+func TestSearchIn_Sort(t *testing.T) {
+	root := searchTree()
+	cases := []struct {
+		sort  string
+		desc  bool
+		first []string
+	}{
+		{SearchSortSize, true, []string{"/r/sub/a.jpg", "/r/f3.txt", "/r/f7.txt"}},
+		{SearchSortSize, false, []string{"/r/f0.txt", "/r/f4.txt", "/r/f8.txt"}},
+		{SearchSortName, false, []string{"/r/sub/a.jpg", "/r/sub/B.jpg", "/r/f0.txt"}},
+		{SearchSortModified, false, []string{"/r/sub/B.jpg", "/r/sub/a.jpg", "/r/f9.txt"}},
+		{SearchSortPath, true, []string{"/r/sub/a.jpg", "/r/sub/B.jpg", "/r/f9.txt"}},
+		{SearchSortDepth, true, []string{"/r/sub/B.jpg", "/r/sub/a.jpg", "/r/f0.txt"}},
+	}
+	for _, c := range cases {
+		res, err := SearchIn(TreeSource(&root), SearchParams{Query: "is:file", Recursive: true, Limit: 3, Sort: c.sort, Desc: c.desc})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(paths(res)); got != fmt.Sprint(c.first) {
+			t.Errorf("%s desc=%v: %s, ожидалось %v", c.sort, c.desc, got, c.first)
+		}
+	}
+	if _, _, err := ParseSearchSort("color", ""); err == nil {
+		t.Error("ожидалась ошибка для неизвестной сортировки")
+	}
+	if _, _, err := ParseSearchSort("size", "up"); err == nil {
+		t.Error("ожидалась ошибка для неизвестного порядка")
+	}
+}
+
+func TestSearchIn_Cursor(t *testing.T) {
+	root := searchTree()
+	for _, sortBy := range []string{"", SearchSortSize, SearchSortName} {
+		params := SearchParams{Query: "is:file", Recursive: true, Limit: 5, Sort: sortBy, Desc: true}
+		full, _ := SearchIn(TreeSource(&root), SearchParams{Query: "is:file", Recursive: true, Sort: sortBy, Desc: true})
+
+		var got []string
+		for pages := 0; ; pages++ {
+			res, err := SearchIn(TreeSource(&root), params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Total != 12 {
+				t.Fatalf("Total на каждой странице — все совпадения: %d", res.Total)
+			}
+			got = append(got, paths(res)...)
+			if res.NextCursor == "" {
+				break
+			}
+			if pages > 3 {
+				t.Fatal("курсор не продвигается")
+			}
+			params.Cursor = res.NextCursor
+		}
+		if fmt.Sprint(got) != fmt.Sprint(paths(full)) {
+			t.Errorf("сортировка %q: страницы по курсору %v\nне совпадают с полной выдачей %v", sortBy, got, paths(full))
+		}
+
+		params.Desc = false
+		if _, err := SearchIn(TreeSource(&root), params); err == nil {
+			t.Errorf("курсор от другой сортировки должен отклоняться")
+		}
+	}
+	if _, err := SearchIn(TreeSource(&root), SearchParams{Cursor: "%%%"}); err == nil {
+		t.Error("ожидалась ошибка для испорченного курсора")
+	}
+}
+
+func TestSearchIn_PathBelowRoot(t *testing.T) {
+	root := searchTree()
+	res, err := SearchIn(TreeSource(&root), SearchParams{Path: "/r/sub/", Recursive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(paths(res)); got != "[/r/sub /r/sub/B.jpg /r/sub/a.jpg]" {
+		t.Errorf("поиск в подкаталоге должен заходить в корень: %s", got)
+	}
+}
+
+func TestSearchIn_Filters(t *testing.T) {
+	root := dupTree()
+	root.FullPathOrig, root.Children[0].FullPathOrig, root.Children[1].FullPathOrig = "/r", "/r/photos", "/r/backup"
+	root.Children[0].Children[0].Perm = "-rwxr-xr-x"
+	root.Children[0].Children[0].Owner, root.Children[0].Children[0].Group = "anna", "staff"
+	root.Children[0].Children[1].Perm = "-rw-r--r--"
+	root.Children[0].Children[1].Owner, root.Children[0].Children[1].Group = "bob", "staff"
+	root.Children[1].Children[2].Md5 = ""
+	root.Children[1].Children[2].Hashes = map[string]string{"sha256": "abc"}
+	root.Children[1].Children[1].Md5 = ""
+
+	cases := []struct {
+		name string
+		p    SearchParams
+		want string
+	}{
+		{"ext", SearchParams{Exts: []string{".TXT"}}, "[/r/photos/n.txt /r/backup/n.txt /r/backup/m.txt]"},
+		{"depth", SearchParams{DepthCmp: map[string]int64{"lte": 1}}, "[/r /r/photos /r/backup]"},
+		{"depth between", SearchParams{DepthCmp: map[string]int64{"between": 1, "between_min": 1, "between_max": 1}}, "[/r/photos /r/backup]"},
+		{"only dir", SearchParams{Only: "dir", Path: "/r/photos"}, "[/r/photos]"},
+		{"only file", SearchParams{Only: "file", Types: []string{"image"}}, "[/r/photos/a.jpg /r/photos/b.jpg /r/backup/a.jpg]"},
+		{"perm octal", SearchParams{Perm: "755"}, "[/r/photos/a.jpg]"},
+		{"perm pattern", SearchParams{Perm: "-rw-*"}, "[/r/photos/b.jpg]"},
+		{"owner", SearchParams{Owners: []string{"bob", "carl"}}, "[/r/photos/b.jpg]"},
+		{"group", SearchParams{Owners: []string{":staff"}}, "[/r/photos/a.jpg /r/photos/b.jpg]"},
+		{"owner and group", SearchParams{Owners: []string{"anna:wheel"}}, "[]"},
+		{"hashed sha256", SearchParams{Hashed: "sha256"}, "[/r/backup/m.txt]"},
+		{"hashed none", SearchParams{Hashed: "none"}, "[/r/backup/n.txt]"},
+		{"size between", SearchParams{SizeCmp: map[string]int64{"between": 1, "between_min": 5, "between_max": 10}}, "[/r/photos/n.txt /r/backup/n.txt /r/backup/m.txt]"},
+	}
+	for _, c := range cases {
+		c.p.Recursive = true
+		res, err := SearchIn(TreeSource(&root), c.p)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got := fmt.Sprint(paths(res)); got != c.want {
+			t.Errorf("%s: %s, ожидалось %s", c.name, got, c.want)
+		}
+	}
+
+	for _, p := range []SearchParams{{Only: "link"}, {Hashed: "crc32"}, {Perm: "999"}, {Perm: "rw+x"}} {
+		if _, err := SearchIn(TreeSource(&root), p); err == nil {
+			t.Errorf("%+v: ожидалась ошибка", p)
+		}
+	}
+}
+
+func TestPermBits(t *testing.T) {
+	cases := map[string]uint32{"-rwxr-xr-x": 0o755, "drw-------": 0o600, "urwxr-xr-x": 0o4755, "dtrwxrwxrwx": 0o1777}
+	for perm, want := range cases {
+		if got, ok := permBits(perm); !ok || got != want {
+			t.Errorf("%s: %o, ожидалось %o", perm, got, want)
+		}
+	}
+	if _, ok := permBits("rwx"); ok {
+		t.Error("короткая строка прав должна отклоняться")
+	}
+}
+
+func TestSearchIn_HashAndLike(t *testing.T) {
+	root := dupTree()
+	root.Children[1].Children[2].Hashes = map[string]string{"sha256": "ff"}
+
+	if _, err := SearchIn(TreeSource(&root), SearchParams{Hashes: map[string]string{"md5": "IMG"}, Recursive: true}); err == nil {
+		t.Fatal("md5=IMG — не шестнадцатеричная строка, ожидалась ошибка")
+	}
+	res, err := SearchIn(TreeSource(&root), SearchParams{Hashes: map[string]string{"md5": "0A"}, Recursive: true})
+	if err != nil || res.Total != 0 {
+		t.Fatalf("md5=0A: %v %+v", err, res)
+	}
+
+	// образец из снимка: остальные копии того же содержимого, без него самого
+	res, err = SearchIn(TreeSource(&root), SearchParams{Like: "/r/photos/a.jpg", Recursive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(paths(res)); got != "[/r/photos/b.jpg /r/backup/a.jpg]" {
+		t.Errorf("like из снимка: %s", got)
+	}
+
+	// образец с диска: хеши считаются по файлу
+	dir := t.TempDir()
+	disk := filepath.Join(dir, "x.txt")
+	if err := os.WriteFile(disk, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sums, _ := FileHashes(disk, []string{"md5", "sha256"})
+	root.Children[0].Children[2].Md5 = sums["md5"]
+	root.Children[1].Children[2].Md5 = sums["md5"]
+	root.Children[1].Children[1].Md5 = ""
+	root.Children[1].Children[1].Hashes = map[string]string{"sha256": sums["sha256"]}
+	if _, err := SearchIn(TreeSource(&root), SearchParams{Like: disk, Recursive: true}); err == nil {
+		t.Fatal("без AllowDiskLike образец с диска читаться не должен")
+	}
+	res, err = SearchIn(TreeSource(&root), SearchParams{Like: disk, Recursive: true, AllowDiskLike: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// m.txt совпадает по md5, но расходится по sha256 — не подходит
+	if got := fmt.Sprint(paths(res)); got != "[/r/photos/n.txt /r/backup/n.txt]" {
+		t.Errorf("like с диска: %s", got)
+	}
+
+	if _, err := SearchIn(TreeSource(&root), SearchParams{Like: filepath.Join(dir, "нет.txt"), AllowDiskLike: true}); err == nil {
+		t.Error("для отсутствующего образца ожидалась ошибка")
+	}
+	// по HTTP ответ одинаков для существующего и отсутствующего на диске файла
+	_, errDisk := SearchIn(TreeSource(&root), SearchParams{Like: disk})
+	_, errNone := SearchIn(TreeSource(&root), SearchParams{Like: filepath.Join(dir, "нет.txt")})
+	if errDisk == nil || errNone == nil ||
+		strings.Replace(errDisk.Error(), disk, "X", 1) != strings.Replace(errNone.Error(), filepath.Join(dir, "нет.txt"), "X", 1) {
+		t.Errorf("ошибки выдают наличие файла: %v / %v", errDisk, errNone)
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fsjson/internal/domain/model"
+)
+
+// nodeFilter — фильтры SearchParams, подготовленные один раз на весь поиск
+type nodeFilter struct {
+	types   map[string]bool
+	exts    map[string]bool
+	owners  []string
+	perm    func(perm string) bool
+	hashes  map[string]string // md5=, sha256=, …
+	like    *likeRef
+	content *contentCond
+}
+
+// compileNodeFilter проверяет значения фильтров и готовит их к сопоставлению
+func compileNodeFilter(p SearchParams) (*nodeFilter, error) {
+	f := &nodeFilter{types: lowerSet(p.Types), exts: lowerSet(p.Exts)}
+	for _, o := range p.Owners {
+		if o = strings.TrimSpace(o); o != "" {
+			f.owners = append(f.owners, o)
+		}
+	}
+	switch p.Only {
+	case "", "dir", "file":
+	default:
+		return nil, fmt.Errorf("некорректный фильтр only=%q: ожидалось dir или file", p.Only)
+	}
+	switch h := p.Hashed; {
+	case h == "", h == "any", h == "none", slices.Contains(HashAlgorithms, h):
+	default:
+		return nil, fmt.Errorf("некорректный фильтр hashed=%q: ожидалось any, none или алгоритм (%s)",
+			h, strings.Join(HashAlgorithms, ", "))
+	}
+	hashes, err := parseHashFilters(p.Hashes)
+	if err != nil {
+		return nil, err
+	}
+	f.hashes = hashes
+	if p.Perm != "" {
+		m, err := compilePerm(p.Perm)
+		if err != nil {
+			return nil, err
+		}
+		f.perm = m
+	}
+	return f, nil
+}
+
+func lowerSet(list []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, s := range list {
+		s = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), ".")
+		if s != "" {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+// match — проверка узла по типу, расширению, глубине, правам, хешам, владельцу и виду
+func (f *nodeFilter) match(n *model.FileInfo, depth int, p SearchParams) bool {
+	if len(f.types) > 0 && !f.types[strings.ToLower(n.FileType)] {
+		return false
+	}
+	if len(f.exts) > 0 && (n.IsDir || !f.exts[strings.TrimPrefix(strings.ToLower(n.Ext), ".")]) {
+		return false
+	}
+	if !matchIntCmp(p.DepthCmp, int64(depth)) {
+		return false
+	}
+	if p.Only == "dir" && !n.IsDir || p.Only == "file" && n.IsDir {
+		return false
+	}
+	if f.perm != nil && !f.perm(n.Perm) {
+		return false
+	}
+	for a, h := range f.hashes {
+		if !strings.EqualFold(NodeHash(n, a), h) {
+			return false
+		}
+	}
+	if f.like != nil && !f.like.match(n) {
+		return false
+	}
+	if f.content != nil && !f.content.match(n) {
+		return false
+	}
+	if len(f.owners) > 0 && !slices.ContainsFunc(f.owners, func(o string) bool { return matchOwner(n, o) }) {
+		return false
+	}
+	switch p.Hashed {
+	case "":
+	case "any":
+		return n.Md5 != "" || len(n.Hashes) > 0
+	case "none":
+		return !n.IsDir && n.Md5 == "" && len(n.Hashes) == 0
+	default:
+		return NodeHash(n, p.Hashed) != ""
+	}
+	return true
+}
+
+// matchIntCmp — фильтры вида size.gt, depth.lte: gt, gte, lt, lte, eq
+// и between (диапазон between_min..between_max включительно)
+func matchIntCmp(cmp map[string]int64, v int64) bool {
+	for op, val := range cmp {
+		var ok bool
+		switch op {
+		case "gt":
+			ok = v > val
+		case "gte":
+			ok = v >= val
+		case "lt":
+			ok = v < val
+		case "lte":
+			ok = v <= val
+		case "eq":
+			ok = v == val
+		case "between":
+			ok = v >= cmp["between_min"] && v <= cmp["between_max"]
+		default:
+			ok = true
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchOwner: "user" — владелец, ":group" — группа, "user:group" — оба
+func matchOwner(n *model.FileInfo, o string) bool {
+	user, group, hasGroup := strings.Cut(o, ":")
+	if user != "" && user != n.Owner {
+		return false
+	}
+	return !hasGroup || group == "" || group == n.Group
+}
+
+var octalPerm = regexp.MustCompile(`^[0-7]{3,4}$`)
+
+// compilePerm — права как в chmod (755, 0644, 4755) или шаблон строки Perm
+// с * и ? ("-rw-r--r--", "d*", "*x")
+func compilePerm(s string) (func(string) bool, error) {
+	if octalPerm.MatchString(s) {
+		want, _ := strconv.ParseUint(s, 8, 32)
+		return func(perm string) bool {
+			bits, ok := permBits(perm)
+			return ok && bits == uint32(want)
+		}, nil
+	}
+	if strings.Trim(s, "-rwxdalTLDpSugct?*") != "" {
+		return nil, fmt.Errorf("некорректный фильтр perm=%q: ожидались права (755, 0644) или шаблон (-rw-r--r--, d*)", s)
+	}
+	re := regexp.MustCompile("^" + wildcardToRegex(s) + "$")
+	return re.MatchString, nil
+}
+
+// permBits — биты прав из строки os.FileMode.String(): девять rwx-символов в конце,
+// setuid, setgid и sticky — буквы u, g, t в префиксе
+func permBits(perm string) (uint32, bool) {
+	if len(perm) < 9 {
+		return 0, false
+	}
+	prefix, rwx := perm[:len(perm)-9], perm[len(perm)-9:]
+	var bits uint32
+	for i, c := range rwx {
+		if c != '-' && c != rune("rwxrwxrwx"[i]) {
+			return 0, false
+		}
+		if c != '-' {
+			bits |= 1 << (8 - i)
+		}
+	}
+	for _, c := range prefix {
+		switch c {
+		case 'u':
+			bits |= 0o4000
+		case 'g':
+			bits |= 0o2000
+		case 't':
+			bits |= 0o1000
+		}
+	}
+	return bits, true
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fsjson/internal/domain/model"
+)
+
+// likeRef — образец для фильтра like: хеши содержимого и размер файла
+type likeRef struct {
+	path   string
+	size   int64
+	hashes map[string]string
+}
+
+// resolveLike находит хеши образца: сначала в снимке (по FullPathOrig или FullPath),
+// а если там файла нет или у него нет хешей и allowDisk — читает файл с диска
+func resolveLike(src NodeSource, path string, allowDisk bool) (*likeRef, error) {
+	path = strings.TrimSpace(path)
+	var found *likeRef
+	err := src(func(n *model.FileInfo, _ int) error {
+		if n.FullPathOrig == path || n.FullPath == path {
+			if !n.IsDir {
+				found = &likeRef{path: n.FullPathOrig, size: n.SizeBytes, hashes: nodeHashes(n)}
+			}
+			return ErrStopWalk
+		}
+		if n.IsDir && !strings.HasPrefix(path, n.FullPathOrig) && !strings.HasPrefix(path, n.FullPath) {
+			return ErrSkipChildren
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found != nil && len(found.hashes) > 0 {
+		return found, nil
+	}
+	if !allowDisk {
+		// одно сообщение на оба случая: ответ не должен выдавать, есть ли файл на сервере
+		return nil, fmt.Errorf("like: в снимке нет файла %s с хешами", path)
+	}
+
+	st, err := os.Stat(path)
+	if err != nil || !st.Mode().IsRegular() {
+		if found != nil {
+			return nil, fmt.Errorf("like: у файла %s в снимке нет хешей, а на диске он недоступен", path)
+		}
+		return nil, fmt.Errorf("like: файл %s не найден ни в снимке, ни на диске", path)
+	}
+	sums, err := FileHashes(path, HashAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("like: %w", err)
+	}
+	abs, _ := filepath.Abs(path)
+	return &likeRef{path: abs, size: st.Size(), hashes: sums}, nil
+}
+
+// nodeHashes — все сохранённые хеши узла по алгоритмам
+func nodeHashes(n *model.FileInfo) map[string]string {
+	out := make(map[string]string)
+	for _, a := range HashAlgorithms {
+		if h := NodeHash(n, a); h != "" {
+			out[a] = strings.ToLower(h)
+		}
+	}
+	return out
+}
+
+// match — тот же размер и совпадение по всем общим алгоритмам (хотя бы по одному);
+// сам образец не подходит
+func (l *likeRef) match(n *model.FileInfo) bool {
+	if n.IsDir || n.SizeBytes != l.size || n.FullPathOrig == l.path {
+		return false
+	}
+	common := false
+	for a, h := range l.hashes {
+		if v := NodeHash(n, a); v != "" {
+			if !strings.EqualFold(v, h) {
+				return false
+			}
+			common = true
+		}
+	}
+	return common
+}
+
+// parseHashFilters проверяет значения md5=, sha256= и т.п.
+func parseHashFilters(m map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(m))
+	for a, h := range m {
+		if _, err := NewHash(a); err != nil {
+			return nil, err
+		}
+		h = strings.ToLower(strings.TrimSpace(h))
+		if _, err := hex.DecodeString(h); err != nil || h == "" {
+			return nil, fmt.Errorf("некорректный хеш %s=%q: ожидалась шестнадцатеричная строка", a, h)
+		}
+		out[a] = h
+	}
+	return out, nil
+}
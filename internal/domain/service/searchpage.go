@@ -0,0 +1,131 @@
+package service
+
+import (
+	"cmp"
+	"container/heap"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fsjson/internal/domain/model"
+)
+
+// searchHit — найденный узел вместе с ключом сортировки.
+// seq — порядковый номер среди совпадений: при равных ключах и путях порядок обхода.
+type searchHit struct {
+	res SearchResult
+	num int64
+	str string
+	seq int
+}
+
+func newSearchHit(n *model.FileInfo, depth, seq int, sortBy string) searchHit {
+	h := searchHit{
+		res: SearchResult{
+			FullPathOrig: n.FullPathOrig,
+			SizeBytes:    n.SizeBytes,
+			FileType:     n.FileType,
+			Modified:     n.Updated,
+			Created:      n.Created,
+		},
+		seq: seq,
+	}
+	switch sortBy {
+	case SearchSortName:
+		name := n.FullName
+		if name == "" {
+			name = filepath.Base(n.FullPathOrig)
+		}
+		h.str = strings.ToLower(name)
+	case SearchSortSize:
+		h.num = n.SizeBytes
+	case SearchSortModified:
+		h.num = n.Updated.UnixNano()
+	case SearchSortCreated:
+		h.num = n.Created.UnixNano()
+	case SearchSortPath:
+		h.str = n.FullPathOrig
+	case SearchSortDepth:
+		h.num = int64(depth)
+	default:
+		h.num = int64(seq)
+	}
+	return h
+}
+
+// hitHeap — ограниченная куча: наверху худший из оставленных, чтобы вытеснять его
+type hitHeap struct {
+	items []searchHit
+	desc  bool
+}
+
+// compare — порядок выдачи: ключ (по возрастанию или убыванию), затем путь и порядок обхода
+func (h *hitHeap) compare(a, b *searchHit) int {
+	c := cmp.Or(cmp.Compare(a.num, b.num), strings.Compare(a.str, b.str))
+	if h.desc {
+		c = -c
+	}
+	return cmp.Or(c, strings.Compare(a.res.FullPathOrig, b.res.FullPathOrig), cmp.Compare(a.seq, b.seq))
+}
+
+func (h *hitHeap) Len() int           { return len(h.items) }
+func (h *hitHeap) Less(i, j int) bool { return h.compare(&h.items[i], &h.items[j]) > 0 }
+func (h *hitHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *hitHeap) Push(x any)         { h.items = append(h.items, x.(searchHit)) }
+func (h *hitHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// push добавляет совпадение, оставляя не больше limit лучших (limit < 0 — без ограничения)
+func (h *hitHeap) push(hit searchHit, limit int) {
+	switch {
+	case limit < 0:
+		h.items = append(h.items, hit)
+	case len(h.items) < limit:
+		heap.Push(h, hit)
+	case h.compare(&hit, &h.items[0]) < 0:
+		h.items[0] = hit
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted — оставленные совпадения в порядке выдачи
+func (h *hitHeap) sorted() []searchHit {
+	sort.Slice(h.items, func(i, j int) bool { return h.compare(&h.items[i], &h.items[j]) < 0 })
+	return h.items
+}
+
+// searchCursor — позиция последнего выданного результата; сортировка
+// запоминается, чтобы курсор нельзя было применить к другой выдаче
+type searchCursor struct {
+	Sort string `json:"o,omitempty"`
+	Desc bool   `json:"d,omitempty"`
+	Num  int64  `json:"n,omitempty"`
+	Str  string `json:"s,omitempty"`
+	Path string `json:"p"`
+	Seq  int    `json:"q"`
+}
+
+var errBadCursor = errors.New("некорректный курсор: получите новый, начав с первой страницы")
+
+func encodeSearchCursor(h *searchHit, p SearchParams) string {
+	data, _ := json.Marshal(searchCursor{Sort: p.Sort, Desc: p.Desc, Num: h.num, Str: h.str, Path: h.res.FullPathOrig, Seq: h.seq})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSearchCursor(s string, p SearchParams) (*searchHit, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errBadCursor
+	}
+	var c searchCursor
+	if json.Unmarshal(data, &c) != nil || c.Sort != p.Sort || c.Desc != p.Desc {
+		return nil, errBadCursor
+	}
+	return &searchHit{res: SearchResult{FullPathOrig: c.Path}, num: c.Num, str: c.Str, seq: c.Seq}, nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"sort"
+
+	"fsjson/internal/domain/model"
+)
+
+// SimilarImage — изображение в кластере похожих
+type SimilarImage struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Distance int    `json:"distance"` // расстояние Хэмминга до первого (самого большого) файла
+}
+
+// ImageCluster — группа визуально похожих изображений
+type ImageCluster struct {
+	Algo   string         `json:"algo"`
+	Images []SimilarImage `json:"images"`
+}
+
+// SimilarImagesResponse — результат поиска похожих изображений
+type SimilarImagesResponse struct {
+	MaxDistance int            `json:"max_distance"`
+	Hashed      int            `json:"hashed"` // изображений с перцептивным хешем
+	Clusters    []ImageCluster `json:"clusters"`
+}
+
+// imageItem — изображение с разобранным хешем
+type imageItem struct {
+	path string
+	size int64
+	hash uint64
+}
+
+// FindSimilarImagesIn собирает изображения с ImageHash и объединяет в кластеры те,
+// что отличаются не более чем на maxDistance бит (цепочкой: A~B и B~C — один кластер).
+// Хеши разных алгоритмов между собой не сравниваются.
+func FindSimilarImagesIn(src NodeSource, maxDistance int) (SimilarImagesResponse, error) {
+	byAlgo := make(map[string][]imageItem)
+	err := src(func(n *model.FileInfo, _ int) error {
+		if n.IsDir || n.ImageHash == "" {
+			return nil
+		}
+		algo, h, err := ParseImageHash(n.ImageHash)
+		if err != nil {
+			return nil // битое значение — просто не участвует
+		}
+		byAlgo[algo] = append(byAlgo[algo], imageItem{path: n.FullPathOrig, size: n.SizeBytes, hash: h})
+		return nil
+	})
+	if err != nil {
+		return SimilarImagesResponse{}, err
+	}
+
+	resp := SimilarImagesResponse{MaxDistance: maxDistance, Clusters: []ImageCluster{}}
+	algos := make([]string, 0, len(byAlgo))
+	for a := range byAlgo {
+		algos = append(algos, a)
+	}
+	sort.Strings(algos)
+	for _, algo := range algos {
+		items := byAlgo[algo]
+		resp.Hashed += len(items)
+		for _, c := range clusterImages(items, maxDistance) {
+			c.Algo = algo
+			resp.Clusters = append(resp.Clusters, c)
+		}
+	}
+	sort.SliceStable(resp.Clusters, func(i, j int) bool {
+		return len(resp.Clusters[i].Images) > len(resp.Clusters[j].Images)
+	})
+	return resp, nil
+}
+
+// clusterImages — кластеры из двух и более изображений. Соседи ищутся через BK-дерево,
+// чтобы не сравнивать все пары.
+func clusterImages(items []imageItem, maxDistance int) []ImageCluster {
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	tree := &bkTree{}
+	for i := range items {
+		tree.search(items, items[i].hash, maxDistance, func(j int) {
+			parent[find(i)] = find(j)
+		})
+		tree.insert(items, i)
+	}
+
+	groups := make(map[int][]int)
+	for i := range items {
+		r := find(i)
+		groups[r] = append(groups[r], i)
+	}
+	var out []ImageCluster
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		// первым — самый большой файл: обычно это оригинал
+		sort.Slice(members, func(a, b int) bool {
+			ia, ib := items[members[a]], items[members[b]]
+			if ia.size != ib.size {
+				return ia.size > ib.size
+			}
+			return ia.path < ib.path
+		})
+		ref := items[members[0]].hash
+		c := ImageCluster{}
+		for _, m := range members {
+			c.Images = append(c.Images, SimilarImage{
+				Path: items[m].path, Size: items[m].size, Distance: HammingDistance(ref, items[m].hash),
+			})
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Images[0].Path < out[j].Images[0].Path })
+	return out
+}
+
+// bkTree — BK-дерево по расстоянию Хэмминга (хранит индексы items)
+type bkTree struct {
+	root *bkNode
+}
+
+type bkNode struct {
+	item     int
+	children map[int]*bkNode
+}
+
+func (t *bkTree) insert(items []imageItem, i int) {
+	if t.root == nil {
+		t.root = &bkNode{item: i}
+		return
+	}
+	n := t.root
+	for {
+		d := HammingDistance(items[n.item].hash, items[i].hash)
+		child, ok := n.children[d]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[int]*bkNode)
+			}
+			n.children[d] = &bkNode{item: i}
+			return
+		}
+		n = child
+	}
+}
+
+// search вызывает fn для всех элементов на расстоянии не больше max от h
+func (t *bkTree) search(items []imageItem, h uint64, max int, fn func(i int)) {
+	if t.root == nil {
+		return
+	}
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		d := HammingDistance(items[n.item].hash, h)
+		if d <= max {
+			fn(n.item)
+		}
+		for cd, child := range n.children {
+			if cd >= d-max && cd <= d+max {
+				stack = append(stack, child)
+			}
+		}
+	}
+}
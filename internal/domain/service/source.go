@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+
+	"fsjson/internal/domain/model"
+)
+
+// ErrSkipChildren — возвращается обработчиком узла, чтобы не заходить в его потомков
+var ErrSkipChildren = errors.New("skip children")
+
+// ErrStopWalk — возвращается обработчиком узла, чтобы досрочно прекратить обход
+var ErrStopWalk = errors.New("stop walk")
+
+// NodeVisitor — обработчик одного узла при обходе (depth — глубина от корня).
+// Потоковые источники не заполняют Children: потомки приходят
+// отдельными вызовами сразу после родителя.
+type NodeVisitor func(n *model.FileInfo, depth int) error
+
+// NodeSource — источник узлов: дерево в памяти или потоковый JSON.
+// Источник можно обходить повторно.
+type NodeSource func(visit NodeVisitor) error
+
+// TreeSource — обход дерева в памяти в том же порядке, что и у потокового декодера
+func TreeSource(root *model.FileInfo) NodeSource {
+	return func(visit NodeVisitor) error {
+		if err := walkTree(root, 0, visit); err != nil && err != ErrStopWalk {
+			return err
+		}
+		return nil
+	}
+}
+
+func walkTree(n *model.FileInfo, depth int, visit NodeVisitor) error {
+	if err := visit(n, depth); err != nil {
+		if err == ErrSkipChildren {
+			return nil
+		}
+		return err
+	}
+	for i := range n.Children {
+		if err := walkTree(&n.Children[i], depth+1, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildTreeFrom собирает дерево из источника узлов (обратная операция к обходу).
+// Если источник отдаёт несколько корней (flat []FileInfo), дерево
+// собирается через AssembleNestedFromFlat.
+func BuildTreeFrom(src NodeSource) (model.FileInfo, error) {
+	roots, err := CollectRoots(src)
+	if err != nil {
+		return model.FileInfo{}, err
+	}
+	if len(roots) == 1 {
+		return roots[0], nil
+	}
+	return AssembleNestedFromFlat(roots), nil
+}
+
+// CollectRoots собирает узлы источника обратно в деревья и возвращает их корни
+// (для flat-массива — все его элементы)
+func CollectRoots(src NodeSource) ([]model.FileInfo, error) {
+	var roots []model.FileInfo
+	var stack []*model.FileInfo
+
+	err := src(func(n *model.FileInfo, depth int) error {
+		node := *n
+		node.Children = nil
+		if depth == 0 {
+			roots = append(roots, node)
+			stack = append(stack[:0], &roots[len(roots)-1])
+			return nil
+		}
+		if depth > len(stack) {
+			return errors.New("нарушен порядок обхода: пропущен родитель")
+		}
+		stack = stack[:depth]
+		parent := stack[depth-1]
+		parent.Children = append(parent.Children, node)
+		stack = append(stack, &parent.Children[len(parent.Children)-1])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
+// CountTree считает файлы, директории и суммарный размер файлов дерева
+func CountTree(root *model.FileInfo) model.SnapshotCounts {
+	var c model.SnapshotCounts
+	_ = TreeSource(root)(func(n *model.FileInfo, _ int) error {
+		if n.IsDir {
+			c.Dirs++
+		} else {
+			c.Files++
+			c.Bytes += n.SizeBytes
+		}
+		return nil
+	})
+	return c
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fsjson/internal/domain/model"
+)
+
+// Поля группировки статистики
+const (
+	StatsByType  = "type"  // FileType
+	StatsByExt   = "ext"   // расширение без точки
+	StatsByDir   = "dir"   // каталог на глубине N от корня: dir:1 — верхний уровень
+	StatsByOwner = "owner" // владелец (user:group)
+	StatsByYear  = "year"  // год изменения
+	StatsByMonth = "month" // месяц изменения: 2025-03
+	StatsBySize  = "size"  // диапазон размера (см. statsSizeBuckets)
+)
+
+// Сортировка групп статистики
+const (
+	StatsSortSum   = "sum" // по суммарному размеру (по умолчанию, по убыванию)
+	StatsSortCount = "count"
+	StatsSortAvg   = "avg"
+	StatsSortMin   = "min"
+	StatsSortMax   = "max"
+	StatsSortKey   = "key" // по значениям полей (по возрастанию)
+)
+
+// StatsKey — поле группировки; Depth — глубина каталога для dir
+type StatsKey struct {
+	Field string
+	Depth int
+}
+
+func (k StatsKey) String() string {
+	if k.Field == StatsByDir {
+		return fmt.Sprintf("%s:%d", k.Field, k.Depth)
+	}
+	return k.Field
+}
+
+// StatsParams — что и как считать. Учитываются только файлы, подходящие под Search
+// (те же запрос и фильтры, что у поиска); Sort, Limit и страницы Search не используются.
+type StatsParams struct {
+	Search  SearchParams
+	GroupBy []StatsKey // пусто — одна группа на все файлы
+	Sort    string     // см. StatsSort*
+	Desc    bool
+	Limit   int // сколько групп вернуть (0 — все)
+}
+
+// StatsGroup — метрики размера файлов одной группы
+type StatsGroup struct {
+	Key   []string `json:"key"` // значения полей в порядке GroupBy
+	Count int64    `json:"count"`
+	Sum   int64    `json:"sum"`
+	Min   int64    `json:"min"`
+	Max   int64    `json:"max"`
+	Avg   float64  `json:"avg"`
+
+	order []int // номер диапазона размера для сортировки по ключу
+}
+
+// StatsResponse — группы (страница после сортировки) и итог по всем файлам
+type StatsResponse struct {
+	GroupBy []string     `json:"group_by"`
+	Groups  []StatsGroup `json:"groups"`
+	Total   StatsGroup   `json:"total"`
+	Count   int          `json:"total_groups"` // групп всего, без учёта Limit
+}
+
+// statsSizeBuckets — верхние границы диапазонов размера (не включительно)
+var statsSizeBuckets = []struct {
+	below int64
+	label string
+}{
+	{1, "0 B"},
+	{1 << 10, "< 1 KB"},
+	{1 << 20, "1 KB – 1 MB"},
+	{10 << 20, "1 – 10 MB"},
+	{100 << 20, "10 – 100 MB"},
+	{1 << 30, "100 MB – 1 GB"},
+	{10 << 30, "1 – 10 GB"},
+	{1<<63 - 1, "≥ 10 GB"},
+}
+
+// ParseStatsGroupBy разбирает поля группировки через запятую: type, ext, dir[:N], owner, year, month, size
+func ParseStatsGroupBy(s string) ([]StatsKey, error) {
+	var keys []StatsKey
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		field, depth, hasDepth := strings.Cut(f, ":")
+		k := StatsKey{Field: field}
+		switch field {
+		case StatsByType, StatsByExt, StatsByOwner, StatsByYear, StatsByMonth, StatsBySize:
+			if hasDepth {
+				return nil, fmt.Errorf("группировка %q: глубина задаётся только для dir", f)
+			}
+		case StatsByDir:
+			k.Depth = 1
+			if hasDepth {
+				n, err := strconv.Atoi(depth)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("группировка %q: ожидалась глубина dir:N", f)
+				}
+				k.Depth = n
+			}
+		default:
+			return nil, fmt.Errorf("неизвестное поле группировки %q (поддерживаются: type, ext, dir:N, owner, year, month, size)", f)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// ParseStatsSort проверяет сортировку групп (order: asc, desc или "" — для key по возрастанию,
+// для метрик по убыванию)
+func ParseStatsSort(sort, order string) (string, bool, error) {
+	sort = strings.ToLower(strings.TrimSpace(sort))
+	switch sort {
+	case "":
+		sort = StatsSortSum
+	case StatsSortSum, StatsSortCount, StatsSortAvg, StatsSortMin, StatsSortMax, StatsSortKey:
+	default:
+		return "", false, fmt.Errorf("неизвестная сортировка статистики %q (поддерживаются: sum, count, avg, min, max, key)", sort)
+	}
+	switch strings.ToLower(strings.TrimSpace(order)) {
+	case "":
+		return sort, sort != StatsSortKey, nil
+	case "asc":
+		return sort, false, nil
+	case "desc":
+		return sort, true, nil
+	}
+	return "", false, fmt.Errorf("неизвестный порядок %q (asc или desc)", order)
+}
+
+// Aggregate группирует найденные файлы и считает по каждой группе количество,
+// суммарный, минимальный, максимальный и средний размер.
+// Обход потоковый: в памяти держатся только группы.
+func Aggregate(src NodeSource, p StatsParams) (StatsResponse, error) {
+	resp := StatsResponse{GroupBy: []string{}, Groups: []StatsGroup{}, Total: StatsGroup{Key: []string{}}}
+	for _, k := range p.GroupBy {
+		resp.GroupBy = append(resp.GroupBy, k.String())
+	}
+	scope, err := compileSearch(src, p.Search)
+	if err != nil {
+		return resp, err
+	}
+	loc := cmp.Or(p.Search.Location, time.Local)
+
+	groups := make(map[string]*StatsGroup)
+	var dirs []string // FullPathOrig каталогов текущей ветки обхода по глубине
+	err = scope.walk(trackDirs(src, &dirs), func(n *model.FileInfo, depth int) {
+		if n.IsDir {
+			return
+		}
+		key, order := make([]string, len(p.GroupBy)), make([]int, len(p.GroupBy))
+		for i, k := range p.GroupBy {
+			key[i], order[i] = statsValue(k, n, depth, dirs, loc)
+		}
+		id := strings.Join(key, "\x00")
+		g := groups[id]
+		if g == nil {
+			g = &StatsGroup{Key: key, order: order}
+			groups[id] = g
+		}
+		g.add(n.SizeBytes)
+		resp.Total.add(n.SizeBytes)
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	for _, g := range groups {
+		g.finish()
+		resp.Groups = append(resp.Groups, *g)
+	}
+	resp.Total.finish()
+	resp.Count = len(resp.Groups)
+	slices.SortFunc(resp.Groups, func(a, b StatsGroup) int {
+		c := compareStats(&a, &b, p.Sort)
+		if p.Desc {
+			c = -c
+		}
+		return cmp.Or(c, compareStatsKeys(&a, &b))
+	})
+	if p.Limit > 0 && len(resp.Groups) > p.Limit {
+		resp.Groups = resp.Groups[:p.Limit]
+	}
+	return resp, nil
+}
+
+// trackDirs — источник, который запоминает путь к текущему узлу: dirs[d] — каталог на глубине d
+func trackDirs(src NodeSource, dirs *[]string) NodeSource {
+	return func(visit NodeVisitor) error {
+		return src(func(n *model.FileInfo, depth int) error {
+			if n.IsDir {
+				*dirs = append((*dirs)[:min(depth, len(*dirs))], n.FullPathOrig)
+			}
+			return visit(n, depth)
+		})
+	}
+}
+
+// statsValue — значение поля группировки для файла и номер для сортировки по ключу
+func statsValue(k StatsKey, n *model.FileInfo, depth int, dirs []string, loc *time.Location) (string, int) {
+	switch k.Field {
+	case StatsByType:
+		return n.FileType, 0
+	case StatsByExt:
+		return strings.ToLower(strings.TrimPrefix(n.Ext, ".")), 0
+	case StatsByDir:
+		// файл не глубже N относится к своему каталогу; в плоском снимке ветки нет — тоже
+		if k.Depth < depth && k.Depth < len(dirs) {
+			return dirs[k.Depth], 0
+		}
+		return filepath.Dir(n.FullPathOrig), 0
+	case StatsByOwner:
+		if n.Group == "" {
+			return n.Owner, 0
+		}
+		return n.Owner + ":" + n.Group, 0
+	case StatsByYear:
+		return n.Updated.In(loc).Format("2006"), 0
+	case StatsByMonth:
+		return n.Updated.In(loc).Format("2006-01"), 0
+	case StatsBySize:
+		for i, b := range statsSizeBuckets {
+			if n.SizeBytes < b.below {
+				return b.label, i
+			}
+		}
+		last := len(statsSizeBuckets) - 1
+		return statsSizeBuckets[last].label, last
+	}
+	return "", 0
+}
+
+func (g *StatsGroup) add(size int64) {
+	if g.Count == 0 || size < g.Min {
+		g.Min = size
+	}
+	g.Max = max(g.Max, size)
+	g.Sum += size
+	g.Count++
+}
+
+func (g *StatsGroup) finish() {
+	if g.Count > 0 {
+		g.Avg = float64(g.Sum) / float64(g.Count)
+	}
+}
+
+func compareStats(a, b *StatsGroup, sort string) int {
+	switch sort {
+	case StatsSortCount:
+		return cmp.Compare(a.Count, b.Count)
+	case StatsSortAvg:
+		return cmp.Compare(a.Avg, b.Avg)
+	case StatsSortMin:
+		return cmp.Compare(a.Min, b.Min)
+	case StatsSortMax:
+		return cmp.Compare(a.Max, b.Max)
+	case StatsSortKey:
+		return compareStatsKeys(a, b)
+	}
+	return cmp.Compare(a.Sum, b.Sum)
+}
+
+// compareStatsKeys — по значениям полей; диапазоны размера — по возрастанию размера
+func compareStatsKeys(a, b *StatsGroup) int {
+	for i := range a.Key {
+		if c := cmp.Or(cmp.Compare(a.order[i], b.order[i]), strings.Compare(a.Key[i], b.Key[i])); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"fsjson/internal/domain/model"
+)
+
+// statsTree — /r/{a,b}/… с видео и текстами разных лет
+func statsTree() model.FileInfo {
+	file := func(p, typ, ext string, size int64, year int) model.FileInfo {
+		return model.FileInfo{FullName: p[len(p)-len("x."+ext):], FullPath: p, FullPathOrig: p, FileType: typ, Ext: ext,
+			SizeBytes: size, Owner: "u", Updated: time.Date(year, 3, 1, 12, 0, 0, 0, time.UTC)}
+	}
+	dir := func(p string, children ...model.FileInfo) model.FileInfo {
+		return model.FileInfo{IsDir: true, FullPath: p, FullPathOrig: p, Children: children}
+	}
+	return dir("/r",
+		dir("/r/a",
+			file("/r/a/x.mp4", "video", "mp4", 3000, 2024),
+			dir("/r/a/deep", file("/r/a/deep/x.mkv", "video", "mkv", 5000, 2025)),
+		),
+		dir("/r/b", file("/r/b/x.txt", "text", "txt", 10, 2025)),
+		file("/r/x.mp4", "video", "mp4", 0, 2023),
+	)
+}
+
+func statsRows(res StatsResponse) string {
+	out := ""
+	for _, g := range res.Groups {
+		out += fmt.Sprintf("%v=%d/%d/%d/%d/%.0f ", g.Key, g.Count, g.Sum, g.Min, g.Max, g.Avg)
+	}
+	return out
+}
+
+func TestAggregate(t *testing.T) {
+	root := statsTree()
+	run := func(group string, search SearchParams, sort string) string {
+		t.Helper()
+		keys, err := ParseStatsGroupBy(group)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort, desc, err := ParseStatsSort(sort, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		search.Recursive = true
+		res, err := Aggregate(TreeSource(&root), StatsParams{Search: search, GroupBy: keys, Sort: sort, Desc: desc})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return statsRows(res)
+	}
+
+	cases := []struct {
+		group  string
+		search SearchParams
+		sort   string
+		want   string
+	}{
+		// видео по каталогам верхнего уровня; файл в корне — в группе корня
+		{"dir", SearchParams{Types: []string{"video"}}, "", "[/r/a]=2/8000/3000/5000/4000 [/r]=1/0/0/0/0 "},
+		{"dir:2", SearchParams{}, "key", "[/r]=1/0/0/0/0 [/r/a]=1/3000/3000/3000/3000 [/r/a/deep]=1/5000/5000/5000/5000 [/r/b]=1/10/10/10/10 "},
+		{"year", SearchParams{}, "key", "[2023]=1/0/0/0/0 [2024]=1/3000/3000/3000/3000 [2025]=2/5010/10/5000/2505 "},
+		{"type,ext", SearchParams{}, "count", "[video mp4]=2/3000/0/3000/1500 [text txt]=1/10/10/10/10 [video mkv]=1/5000/5000/5000/5000 "},
+		{"size", SearchParams{}, "key", "[0 B]=1/0/0/0/0 [< 1 KB]=1/10/10/10/10 [1 KB – 1 MB]=2/8000/3000/5000/4000 "},
+		{"month,owner", SearchParams{Query: "ext:mkv"}, "", "[2025-03 u]=1/5000/5000/5000/5000 "},
+	}
+	for _, c := range cases {
+		if got := run(c.group, c.search, c.sort); got != c.want {
+			t.Errorf("%s: %s\n ожидалось %s", c.group, got, c.want)
+		}
+	}
+}
+
+func TestAggregate_TotalAndLimit(t *testing.T) {
+	root := statsTree()
+	res, err := Aggregate(TreeSource(&root), StatsParams{
+		Search: SearchParams{Recursive: true}, GroupBy: []StatsKey{{Field: StatsByExt}}, Sort: StatsSortSum, Desc: true, Limit: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total.Count != 4 || res.Total.Sum != 8010 || res.Total.Min != 0 || res.Total.Max != 5000 {
+		t.Errorf("итог: %+v", res.Total)
+	}
+	if res.Count != 3 || len(res.Groups) != 1 || res.Groups[0].Key[0] != "mkv" {
+		t.Errorf("лимит групп: %d %s", res.Count, statsRows(res))
+	}
+}
+
+func TestParseStatsGroupBy_Errors(t *testing.T) {
+	for _, s := range []string{"color", "dir:-1", "dir:x", "type:2"} {
+		if _, err := ParseStatsGroupBy(s); err == nil {
+			t.Errorf("%q: ожидалась ошибка", s)
+		}
+	}
+	if _, _, err := ParseStatsSort("median", ""); err == nil {
+		t.Error("median: ожидалась ошибка")
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"cmp"
+	"container/heap"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fsjson/internal/domain/model"
+)
+
+// DefaultTopN — сколько элементов в каждом списке --top по умолчанию
+const DefaultTopN = 10
+
+// TopParams — узлы, которые учитываются (те же запрос и фильтры, что у поиска), и длина списков
+type TopParams struct {
+	Search SearchParams
+	N      int
+}
+
+// TopEntry — элемент списка: путь и то, по чему он попал в список
+type TopEntry struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`              // для директорий — суммарный (ComputeDirSizes)
+	Entries int    `json:"entries,omitempty"` // элементов в директории (без вложенных)
+	Depth   int    `json:"depth"`
+	Type    string `json:"type,omitempty"`
+}
+
+// TopResponse — самые большие файлы и директории, самые населённые директории и самые глубокие пути
+type TopResponse struct {
+	Files   []TopEntry `json:"largest_files"`
+	Dirs    []TopEntry `json:"largest_dirs"`
+	Crowded []TopEntry `json:"most_entries"`
+	Deepest []TopEntry `json:"deepest"`
+}
+
+// Top находит N самых больших файлов, N самых больших директорий (по SizeBytes,
+// который при сканировании считает ComputeDirSizes), N директорий с наибольшим числом
+// элементов и N самых глубоких путей. Для каждого списка держится куча из N элементов,
+// так что память не зависит от размера снимка. Корни снимка (и начальный путь)
+// в списки директорий не входят; под синтетическим корнем (root) объединённого
+// снимка входят его директории верхнего уровня.
+func Top(src NodeSource, p TopParams) (TopResponse, error) {
+	n := p.N
+	if n <= 0 {
+		n = DefaultTopN
+	}
+	files := newTopHeap(n, func(e *TopEntry) int64 { return e.Size })
+	dirs := newTopHeap(n, func(e *TopEntry) int64 { return e.Size })
+	crowded := newTopHeap(n, func(e *TopEntry) int64 { return int64(e.Entries) })
+	deepest := newTopHeap(n, func(e *TopEntry) int64 { return int64(e.Depth) })
+
+	scope, err := compileSearch(src, p.Search)
+	if err != nil {
+		return TopResponse{}, err
+	}
+	// корни — узлы глубины 0; в плоском снимке глубина 0 у всех, и корнем считается
+	// только узел вне уже встреченных корней (FlattenTree пишет предков раньше потомков)
+	var roots []string
+	isRoot := false
+	withRoot := func(visit NodeVisitor) error {
+		return src(func(node *model.FileInfo, depth int) error {
+			isRoot = depth == 0 && !underAny(node.FullPathOrig, roots)
+			if isRoot {
+				roots = append(roots, node.FullPathOrig)
+			}
+			return visit(node, depth)
+		})
+	}
+	err = scope.walk(withRoot, func(node *model.FileInfo, depth int) {
+		e := TopEntry{Path: node.FullPathOrig, Size: node.SizeBytes, Depth: depth, Type: node.FileType}
+		deepest.push(e)
+		if !node.IsDir {
+			files.push(e)
+			return
+		}
+		if isRoot || scope.start != "" && node.FullPath == scope.start {
+			return
+		}
+		e.Type = ""
+		dirs.push(e)
+		if e.Entries = max(node.ChildCount, len(node.Children)); e.Entries > 0 {
+			crowded.push(e)
+		}
+	})
+	if err != nil {
+		return TopResponse{}, err
+	}
+	return TopResponse{
+		Files:   files.sorted(),
+		Dirs:    dirs.sorted(),
+		Crowded: crowded.sorted(),
+		Deepest: deepest.sorted(),
+	}, nil
+}
+
+// underAny — путь лежит внутри одного из dirs (пустой путь — синтетический корень, в нём всё)
+func underAny(path string, dirs []string) bool {
+	for _, d := range dirs {
+		if d == "" || strings.HasPrefix(path, strings.TrimSuffix(d, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// topHeap — ограниченная куча: наверху худший из оставленных, чтобы вытеснять его
+type topHeap struct {
+	items []TopEntry
+	limit int
+	key   func(e *TopEntry) int64
+}
+
+func newTopHeap(limit int, key func(e *TopEntry) int64) *topHeap {
+	return &topHeap{limit: limit, key: key}
+}
+
+// compare — порядок в списке: ключ по убыванию, при равных — путь по алфавиту
+func (h *topHeap) compare(a, b *TopEntry) int {
+	return cmp.Or(cmp.Compare(h.key(b), h.key(a)), strings.Compare(a.Path, b.Path))
+}
+
+func (h *topHeap) Len() int           { return len(h.items) }
+func (h *topHeap) Less(i, j int) bool { return h.compare(&h.items[i], &h.items[j]) > 0 }
+func (h *topHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *topHeap) Push(x any)         { h.items = append(h.items, x.(TopEntry)) }
+func (h *topHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+func (h *topHeap) push(e TopEntry) {
+	switch {
+	case len(h.items) < h.limit:
+		heap.Push(h, e)
+	case h.compare(&e, &h.items[0]) < 0:
+		h.items[0] = e
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted — оставленные элементы от лучшего к худшему
+func (h *topHeap) sorted() []TopEntry {
+	sort.Slice(h.items, func(i, j int) bool { return h.compare(&h.items[i], &h.items[j]) < 0 })
+	if h.items == nil {
+		return []TopEntry{}
+	}
+	return h.items
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func topPaths(list []TopEntry) []string {
+	out := []string{}
+	for _, e := range list {
+		out = append(out, e.Path)
+	}
+	return out
+}
+
+func TestTop(t *testing.T) {
+	root := statsTree()
+	ComputeDirSizes(&root)
+	for i := range root.Children {
+		root.Children[i].ChildCount = len(root.Children[i].Children)
+	}
+
+	res, err := Top(TreeSource(&root), TopParams{Search: SearchParams{Recursive: true}, N: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"files":   "[/r/a/deep/x.mkv /r/a/x.mp4]",
+		"dirs":    "[/r/a /r/a/deep]", // корень не входит
+		"crowded": "[/r/a /r/a/deep]", // у /r/a/deep ChildCount не заполнен — считаются Children
+		"deepest": "[/r/a/deep/x.mkv /r/a/deep]",
+	}
+	got := map[string]string{
+		"files":   fmt.Sprint(topPaths(res.Files)),
+		"dirs":    fmt.Sprint(topPaths(res.Dirs)),
+		"crowded": fmt.Sprint(topPaths(res.Crowded)),
+		"deepest": fmt.Sprint(topPaths(res.Deepest)),
+	}
+	for k := range want {
+		if got[k] != want[k] {
+			t.Errorf("%s: %s, ожидалось %s", k, got[k], want[k])
+		}
+	}
+	if res.Dirs[0].Size != 8000 || res.Crowded[0].Entries != 2 {
+		t.Errorf("размер и число элементов /r/a: %+v %+v", res.Dirs[0], res.Crowded[0])
+	}
+
+	res, _ = Top(TreeSource(&root), TopParams{Search: SearchParams{Recursive: true, Path: "/r/a"}})
+	if fmt.Sprint(topPaths(res.Dirs)) != "[/r/a/deep]" {
+		t.Errorf("начальный путь не входит в списки директорий: %v", topPaths(res.Dirs))
+	}
+	res, _ = Top(TreeSource(&root), TopParams{Search: SearchParams{Recursive: true, Types: []string{"video"}}})
+	if len(res.Files) != 3 || len(res.Dirs) != 0 {
+		t.Errorf("фильтр по типу: %v %v", topPaths(res.Files), topPaths(res.Dirs))
+	}
+}
+
+func TestTop_BoundedHeap(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	root := model.FileInfo{IsDir: true, FullPath: "/r", FullPathOrig: "/r"}
+	var sizes []int64
+	for i := range 5000 {
+		size := r.Int63n(1000) // много одинаковых размеров — важен порядок по пути
+		p := fmt.Sprintf("/r/f%04d", i)
+		root.Children = append(root.Children, model.FileInfo{FullPath: p, FullPathOrig: p, SizeBytes: size})
+		sizes = append(sizes, size)
+	}
+	res, err := Top(TreeSource(&root), TopParams{Search: SearchParams{Recursive: true}, N: 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx := make([]int, len(sizes))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return sizes[idx[a]] > sizes[idx[b]] })
+	for i, e := range res.Files {
+		if want := fmt.Sprintf("/r/f%04d", idx[i]); e.Path != want {
+			t.Fatalf("место %d: %s (%d), ожидалось %s (%d)", i, e.Path, e.Size, want, sizes[idx[i]])
+		}
+	}
+	if len(res.Files) != 25 {
+		t.Errorf("длина списка: %d", len(res.Files))
+	}
+}
+
+func TestTop_MultiRoot(t *testing.T) {
+	// два снимка под синтетическим (root) с пустым путём: корнем считается только он
+	a, b := statsTree(), statsTree()
+	b.FullPath, b.FullPathOrig = "/s", "/s"
+	b.Children = b.Children[1:2] // только /r/b
+	b.Children[0].FullPathOrig = "/s/b"
+	merged := model.FileInfo{IsDir: true, FullName: "(root)", Children: []model.FileInfo{a, b}}
+	ComputeDirSizes(&merged)
+
+	res, err := Top(TreeSource(&merged), TopParams{Search: SearchParams{Recursive: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(topPaths(res.Dirs)); got != "[/r /r/a /r/a/deep /r/b /s /s/b]" {
+		t.Errorf("директории верхнего уровня объединённого снимка: %s", got)
+	}
+
+	// плоский снимок: у всех узлов глубина 0, корнем остаётся только первый
+	tree := statsTree()
+	ComputeDirSizes(&tree)
+	flat := FlattenTree(tree)
+	flatSource := func(visit NodeVisitor) error {
+		for i := range flat {
+			if err := visit(&flat[i], 0); err != nil && err != ErrSkipChildren {
+				return err
+			}
+		}
+		return nil
+	}
+	res, err = Top(flatSource, TopParams{Search: SearchParams{Recursive: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(topPaths(res.Dirs)); got != "[/r/a /r/a/deep /r/b]" {
+		t.Errorf("плоский снимок: %s", got)
+	}
+}
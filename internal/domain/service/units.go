@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeUnits — множители размеров (двоичные, как в HumanSize)
+var sizeUnits = map[string]int64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+	"p": 1 << 50, "pb": 1 << 50, "pib": 1 << 50,
+}
+
+// ParseSize разбирает размер: "1048576", "512KB", "1.5GB", "2 TiB" (единицы двоичные).
+// Размер, не помещающийся в int64 (например, 10000PB), — ошибка, а не переполнение.
+func ParseSize(s string) (int64, error) {
+	num, unit := splitNumber(s)
+	mul, ok := sizeUnits[strings.ToLower(unit)]
+	if num == "" || !ok {
+		return 0, fmt.Errorf("некорректный размер %q (пример: 100MB, 1.5GB)", s)
+	}
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if n > math.MaxInt64/mul {
+			return 0, fmt.Errorf("некорректный размер %q: больше %s", s, HumanSize(math.MaxInt64))
+		}
+		return n * mul, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("некорректный размер %q (пример: 100MB, 1.5GB)", s)
+	}
+	// float64(math.MaxInt64) == 2^63: всё, что не меньше, в int64 не помещается
+	if v := f * float64(mul); v < math.MaxInt64 {
+		return int64(v), nil
+	}
+	return 0, fmt.Errorf("некорректный размер %q: больше %s", s, HumanSize(math.MaxInt64))
+}
+
+// ageUnits — единицы возраста; месяц и год приближённые
+var ageUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second,
+	"min": time.Minute,
+	"h":   time.Hour,
+	"d":   24 * time.Hour,
+	"w":   7 * 24 * time.Hour,
+	"mo":  30 * 24 * time.Hour,
+	"y":   365 * 24 * time.Hour,
+}
+
+// ParseAge разбирает возраст: "90min", "12h", "30d", "2w", "6mo", "1y"
+func ParseAge(s string) (time.Duration, error) {
+	num, unit := splitNumber(s)
+	mul, ok := ageUnits[strings.ToLower(unit)]
+	if num == "" || !ok {
+		return 0, fmt.Errorf("некорректный возраст %q (единицы: min, h, d, w, mo, y)", s)
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 || f*float64(mul) >= math.MaxInt64 {
+		return 0, fmt.Errorf("некорректный возраст %q", s)
+	}
+	return time.Duration(f * float64(mul)), nil
+}
+
+// splitNumber делит "1.5GB" на "1.5" и "GB" (пробелы между ними допускаются)
+func splitNumber(s string) (num, unit string) {
+	s = strings.TrimSpace(s)
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// dateLayouts — поддерживаемые форматы дат; period — точность: день, месяц или 0 (момент)
+var dateLayouts = []struct {
+	layout string
+	period string
+}{
+	{time.RFC3339Nano, ""},
+	{"2006-01-02T15:04Z07:00", ""},
+	{"2006-01-02T15:04:05", ""},
+	{"2006-01-02T15:04", ""},
+	{"2006-01-02 15:04:05", ""},
+	{"2006-01-02 15:04", ""},
+	{"2006-01-02", "day"},
+	{"2006-01", "month"},
+}
+
+// ParseDate разбирает дату ISO 8601. Дата без зоны понимается в loc (nil — местное время).
+// Для дня и месяца to — начало следующего дня или месяца, для момента to нулевое.
+func ParseDate(s string, loc *time.Location) (from, to time.Time, err error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	s = strings.TrimSpace(s)
+	var errs []error
+	for _, l := range dateLayouts {
+		t, err := time.ParseInLocation(l.layout, s, loc)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		switch l.period {
+		case "day":
+			return t, t.AddDate(0, 0, 1), nil
+		case "month":
+			return t, t.AddDate(0, 1, 0), nil
+		}
+		return t, time.Time{}, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("некорректная дата %q (пример: 2025-01-31, 2025-01-31T10:00+03:00): %w", s, errors.Join(errs...))
+}
+
+// ParseTimeExpr разбирает момент или период времени:
+//
+//	2025-01-31, 2025-01, 2025-01-31T10:00[:00][Z|+03:00] — дата (см. ParseDate);
+//	-7d, 7d, +2h — относительно now (без знака — в прошлое), единицы как у ParseAge;
+//	now, today, yesterday, this-week, last-week, this-month, last-month, this-year, last-year.
+//
+// Для периодов to — начало следующего периода, для момента to нулевое.
+func ParseTimeExpr(s string, now time.Time, loc *time.Location) (from, to time.Time, err error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	s = strings.TrimSpace(s)
+	now = now.In(loc)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	week := day.AddDate(0, 0, -(int(day.Weekday())+6)%7) // понедельник
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	year := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc)
+	switch strings.ToLower(s) {
+	case "now":
+		return now, time.Time{}, nil
+	case "today":
+		return day, day.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return day.AddDate(0, 0, -1), day, nil
+	case "this-week":
+		return week, week.AddDate(0, 0, 7), nil
+	case "last-week":
+		return week.AddDate(0, 0, -7), week, nil
+	case "this-month":
+		return month, month.AddDate(0, 1, 0), nil
+	case "last-month":
+		return month.AddDate(0, -1, 0), month, nil
+	case "this-year":
+		return year, year.AddDate(1, 0, 0), nil
+	case "last-year":
+		return year.AddDate(-1, 0, 0), year, nil
+	}
+
+	sign := -1
+	rel := s
+	if strings.HasPrefix(rel, "+") {
+		sign, rel = 1, rel[1:]
+	} else {
+		rel = strings.TrimPrefix(rel, "-")
+	}
+	if d, err := ParseAge(rel); err == nil {
+		return now.Add(time.Duration(sign) * d), time.Time{}, nil
+	}
+	from, to, err = ParseDate(s, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("некорректное время %q: ожидалась дата (2025-01-31), "+
+			"смещение (-90min, -7d, 2y) или период (today, last-month)", s)
+	}
+	return from, to, nil
+}
+
+// TimeBound — граница для сравнения «время op значение», где значение — момент или период:
+// после периода (>) — не раньше его конца, до периода (<) — раньше его начала,
+// >= и <= включают сам период
+func TimeBound(op string, from, to time.Time) time.Time {
+	if to.IsZero() {
+		return from
+	}
+	switch op {
+	case "gt", ">", "lte", "<=":
+		return to.Add(-time.Nanosecond)
+	}
+	return from
+}
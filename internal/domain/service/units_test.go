@@ -0,0 +1,99 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSizeAndAge(t *testing.T) {
+	sizes := map[string]int64{"100": 100, "1KB": 1024, "1.5GB": 3 << 29, "2 mib": 2 << 20, "10b": 10, "8191PB": 8191 << 50}
+	for in, want := range sizes {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; ожидалось %d", in, got, err, want)
+		}
+	}
+	// последние — не помещаются в int64 и не должны переполняться в мусор
+	for _, bad := range []string{"", "GB", "1XB", "1.2.3MB", "-5", "10000PB", "8192PB", "8192.5PB", "99999999999999999999"} {
+		if _, err := ParseSize(bad); err == nil {
+			t.Errorf("ParseSize(%q): ожидалась ошибка", bad)
+		}
+	}
+	ages := map[string]time.Duration{"90min": 90 * time.Minute, "12h": 12 * time.Hour, "2w": 14 * 24 * time.Hour, "1y": 365 * 24 * time.Hour}
+	for in, want := range ages {
+		if got, err := ParseAge(in); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v; ожидалось %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseAge("30"); err == nil {
+		t.Error("ParseAge без единицы: ожидалась ошибка")
+	}
+	if _, err := ParseAge("1000000y"); err == nil {
+		t.Error("ParseAge больше time.Duration: ожидалась ошибка")
+	}
+}
+
+func TestParseTimeExpr(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*3600)
+	now := time.Date(2025, 3, 5, 14, 30, 0, 0, msk) // среда
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, msk) }
+	cases := []struct {
+		in       string
+		from, to time.Time
+	}{
+		{"now", now, time.Time{}},
+		{"-7d", now.AddDate(0, 0, -7), time.Time{}},
+		{"7d", now.AddDate(0, 0, -7), time.Time{}},
+		{"+2h", now.Add(2 * time.Hour), time.Time{}},
+		{"2y", now.Add(-2 * 365 * 24 * time.Hour), time.Time{}},
+		{"today", day(2025, 3, 5), day(2025, 3, 6)},
+		{"Yesterday", day(2025, 3, 4), day(2025, 3, 5)},
+		{"this-week", day(2025, 3, 3), day(2025, 3, 10)},
+		{"last-week", day(2025, 2, 24), day(2025, 3, 3)},
+		{"last-month", day(2025, 2, 1), day(2025, 3, 1)},
+		{"this-year", day(2025, 1, 1), day(2026, 1, 1)},
+		{"last-year", day(2024, 1, 1), day(2025, 1, 1)},
+		{"2025-01-31", day(2025, 1, 31), day(2025, 2, 1)},
+		{"2024-02", day(2024, 2, 1), day(2024, 3, 1)},
+		{"2025-01-31T10:00", time.Date(2025, 1, 31, 10, 0, 0, 0, msk), time.Time{}},
+		{"2025-01-31T10:00:00Z", time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC), time.Time{}},
+		{"2025-01-31T10:00+05:00", time.Date(2025, 1, 31, 5, 0, 0, 0, time.UTC), time.Time{}},
+	}
+	for _, c := range cases {
+		from, to, err := ParseTimeExpr(c.in, now, msk)
+		if err != nil {
+			t.Errorf("%q: %v", c.in, err)
+			continue
+		}
+		if !from.Equal(c.from) || !to.Equal(c.to) {
+			t.Errorf("%q: [%v, %v), ожидалось [%v, %v)", c.in, from, to, c.from, c.to)
+		}
+	}
+	for _, bad := range []string{"", "soon", "-7", "2025-13-01", "7 days"} {
+		if _, _, err := ParseTimeExpr(bad, now, msk); err == nil {
+			t.Errorf("%q: ожидалась ошибка", bad)
+		}
+	}
+
+	// границы: после периода — с его конца, до периода — раньше начала
+	from, to := day(2025, 2, 1), day(2025, 3, 1)
+	if b := TimeBound("gt", from, to); !b.Before(to) || !b.After(from) {
+		t.Errorf("gt: %v", b)
+	}
+	if b := TimeBound("lt", from, to); !b.Equal(from) {
+		t.Errorf("lt: %v", b)
+	}
+	if b := TimeBound("gt", now, time.Time{}); !b.Equal(now) {
+		t.Errorf("для момента граница — сам момент: %v", b)
+	}
+}
+
+func TestParseDate_Errors(t *testing.T) {
+	_, _, err := ParseDate("31.01.2025", time.UTC)
+	if err == nil {
+		t.Fatal("ожидалась ошибка")
+	}
+	// ошибки всех форматов сохраняются, а не теряются
+	if msg := err.Error(); len(msg) < 100 {
+		t.Errorf("в ошибке должны быть причины по форматам: %s", msg)
+	}
+}
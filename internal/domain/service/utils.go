@@ -62,13 +62,15 @@ func AppendFlatUnique(dst, src []model.FileInfo, seen map[string]struct{}) []mod
 	return dst
 }
 
-// FlattenTree превращает дерево в flat []FileInfo
+// FlattenTree превращает дерево в flat []FileInfo (элементы без Children)
 func FlattenTree(root model.FileInfo) []model.FileInfo {
 	var flat []model.FileInfo
 	var walk func(model.FileInfo)
 	walk = func(node model.FileInfo) {
+		children := node.Children
+		node.Children = nil
 		flat = append(flat, node)
-		for _, c := range node.Children {
+		for _, c := range children {
 			walk(c)
 		}
 	}
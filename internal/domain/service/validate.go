@@ -0,0 +1,145 @@
+package service
+
+import (
+	"fmt"
+
+	"fsjson/internal/domain/model"
+)
+
+// ValidationIssue — нарушение структуры или инварианта снимка
+type ValidationIssue struct {
+	Path    string `json:"path"` // JSON-путь, например $.Children[2].SizeBytes
+	Message string `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	return i.Path + ": " + i.Message
+}
+
+// DirChild — то, что ValidateNode нужно знать о прямом потомке директории
+type DirChild struct {
+	Path      string // JSON-путь потомка
+	ParentDir string
+	SizeBytes int64
+}
+
+// ValidateTree проверяет инварианты дерева (см. ValidateNode) для каждого узла.
+// base — JSON-путь корня ("$" для голого дерева, "$.Tree" для конверта).
+func ValidateTree(root *model.FileInfo, base string) []ValidationIssue {
+	var issues []ValidationIssue
+	var walk func(n *model.FileInfo, path string)
+	walk = func(n *model.FileInfo, path string) {
+		children := make([]DirChild, len(n.Children))
+		for i := range n.Children {
+			c := &n.Children[i]
+			children[i] = DirChild{Path: fmt.Sprintf("%s.Children[%d]", path, i), ParentDir: c.ParentDir, SizeBytes: c.SizeBytes}
+			walk(c, children[i].Path)
+		}
+		issues = append(issues, ValidateNode(n, path, children)...)
+	}
+	walk(root, base)
+	return issues
+}
+
+// ValidateNode проверяет инварианты одного узла по сводке его прямых потомков,
+// так что дерево можно проверять и потоково, не держа его в памяти:
+//   - ParentDir потомка совпадает с FullPath родителя
+//   - размер директории равен сумме размеров потомков
+//   - ChildCount не меньше количества Children: сканер берёт его из os.ReadDir,
+//     а исключённые (--exclude) и нечитаемые элементы в Children не попадают
+//   - у файлов нет Children
+func ValidateNode(n *model.FileInfo, path string, children []DirChild) []ValidationIssue {
+	var issues []ValidationIssue
+	add := func(path, format string, args ...any) {
+		issues = append(issues, ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !n.IsDir {
+		if len(children) > 0 {
+			add(path+".Children", "у файла %q есть потомки (%d)", n.FullName, len(children))
+		}
+		return issues
+	}
+
+	// синтетические корни ((root), результат --merge-children) собраны из разных деревьев
+	synthetic := n.FullPath == "" || n.FileType == "merged"
+
+	var total int64
+	for _, c := range children {
+		total += c.SizeBytes
+		if !synthetic && c.ParentDir != n.FullPath {
+			add(c.Path+".ParentDir", "ожидалось %q, получено %q", n.FullPath, c.ParentDir)
+		}
+	}
+	if n.SizeBytes != total {
+		add(path+".SizeBytes", "размер директории %d не равен сумме потомков %d", n.SizeBytes, total)
+	}
+	if n.ChildCount < len(children) {
+		add(path+".ChildCount", "ChildCount=%d меньше числа потомков %d", n.ChildCount, len(children))
+	}
+	return issues
+}
+
+// ValidateFlat проверяет flat-массив: уникальность FullPath и отсутствие вложенных Children
+func ValidateFlat(items []model.FileInfo, base string) []ValidationIssue {
+	var issues []ValidationIssue
+	v := NewFlatValidator(base)
+	for i := range items {
+		issues = append(issues, v.Add(i, &items[i], len(items[i].Children))...)
+	}
+	return issues
+}
+
+// FlatValidator проверяет flat-массив по одному элементу; в памяти остаются только пути
+type FlatValidator struct {
+	base string
+	seen map[string]int
+}
+
+// NewFlatValidator — проверка массива с JSON-путём base ("$" или "$.Items")
+func NewFlatValidator(base string) *FlatValidator {
+	return &FlatValidator{base: base, seen: make(map[string]int)}
+}
+
+// Add проверяет i-й элемент; children — сколько у него Children
+func (v *FlatValidator) Add(i int, item *model.FileInfo, children int) []ValidationIssue {
+	var issues []ValidationIssue
+	path := fmt.Sprintf("%s[%d]", v.base, i)
+	if j, ok := v.seen[item.FullPath]; ok {
+		issues = append(issues, ValidationIssue{
+			Path:    path + ".FullPath",
+			Message: fmt.Sprintf("повтор пути %q (уже есть в %s[%d])", item.FullPath, v.base, j),
+		})
+	} else {
+		v.seen[item.FullPath] = i
+	}
+	if children > 0 {
+		issues = append(issues, ValidationIssue{
+			Path:    path + ".Children",
+			Message: "элементы flat-массива не должны содержать Children",
+		})
+	}
+	return issues
+}
+
+// ValidateCounts сверяет счётчики конверта с фактическим деревом
+func ValidateCounts(counts model.SnapshotCounts, root *model.FileInfo, base string) []ValidationIssue {
+	return CompareCounts(counts, CountTree(root), base)
+}
+
+// CompareCounts сверяет счётчики конверта с посчитанными при обходе
+func CompareCounts(counts, actual model.SnapshotCounts, base string) []ValidationIssue {
+	var issues []ValidationIssue
+	check := func(field string, want, got int64) {
+		if want != got {
+			issues = append(issues, ValidationIssue{
+				Path:    base + ".Counts." + field,
+				Message: fmt.Sprintf("указано %d, в дереве %d", want, got),
+			})
+		}
+	}
+	check("Files", counts.Files, actual.Files)
+	check("Dirs", counts.Dirs, actual.Dirs)
+	check("Bytes", counts.Bytes, actual.Bytes)
+	return issues
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"fsjson/internal/domain/model"
+)
+
+func TestValidateTree(t *testing.T) {
+	root := model.FileInfo{
+		IsDir: true, FullName: "root", FullPath: "/root", SizeBytes: 30, ChildCount: 2,
+		Children: []model.FileInfo{
+			{FullName: "a.txt", FullPath: "/root/a.txt", ParentDir: "/root", SizeBytes: 10},
+			{FullName: "b.txt", FullPath: "/root/b.txt", ParentDir: "/root", SizeBytes: 20},
+		},
+	}
+	if issues := ValidateTree(&root, "$"); len(issues) != 0 {
+		t.Fatalf("ожидалось корректное дерево, получено %v", issues)
+	}
+
+	root.Children[1].ParentDir = "/other"
+	root.SizeBytes = 31
+	root.ChildCount = 1
+	issues := ValidateTree(&root, "$")
+
+	want := map[string]bool{
+		"$.Children[1].ParentDir": true,
+		"$.SizeBytes":             true,
+		"$.ChildCount":            true,
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("ожидалось %d нарушения, получено %v", len(want), issues)
+	}
+	for _, is := range issues {
+		if !want[is.Path] {
+			t.Fatalf("неожиданное нарушение: %s", is)
+		}
+	}
+}
+
+func TestValidateTree_ExcludedChildren(t *testing.T) {
+	// ChildCount из os.ReadDir учитывает и то, что отсеял --exclude
+	root := model.FileInfo{IsDir: true, FullPath: "/root", ChildCount: 1, Children: []model.FileInfo{
+		{IsDir: true, FullPath: "/root/sub", ParentDir: "/root", ChildCount: 3},
+	}}
+	if issues := ValidateTree(&root, "$"); len(issues) != 0 {
+		t.Fatalf("ChildCount больше числа потомков — не нарушение: %v", issues)
+	}
+}
+
+func TestValidateFlat_DuplicatePaths(t *testing.T) {
+	items := []model.FileInfo{
+		{FullPath: "/a"},
+		{FullPath: "/b"},
+		{FullPath: "/a"},
+	}
+	issues := ValidateFlat(items, "$")
+	if len(issues) != 1 || issues[0].Path != "$[2].FullPath" {
+		t.Fatalf("ожидался повтор в $[2].FullPath, получено %v", issues)
+	}
+}
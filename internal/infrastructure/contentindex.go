@@ -0,0 +1,53 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+)
+
+// ContentIndexPath — файл индекса содержимого рядом со снимком: structure.json → structure.content.json
+func ContentIndexPath(snapshot string) string {
+	return strings.TrimSuffix(snapshot, filepath.Ext(snapshot)) + ".content.json"
+}
+
+// WriteContentIndexAtomic записывает индекс содержимого (и подписывает, если задан --sign-key)
+func WriteContentIndexAtomic(output string, idx *model.ContentIndex) {
+	writeJSONAtomic(output, idx, false)
+}
+
+// ReadContentIndex читает индекс содержимого
+func ReadContentIndex(path string) (*model.ContentIndex, error) {
+	f, err := OpenVerified(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var idx model.ContentIndex
+	if err := json.NewDecoder(f).Decode(&idx); err != nil {
+		return nil, fmt.Errorf("ошибка разбора индекса содержимого %s: %w", path, err)
+	}
+	return &idx, nil
+}
+
+// ContentIndexLoader — загрузка индекса содержимого снимка при первом обращении
+// (для SearchParams.ContentIndex); индекс читается один раз
+func ContentIndexLoader(snapshot string) func() (*service.ContentSearcher, error) {
+	path := ContentIndexPath(snapshot)
+	return sync.OnceValues(func() (*service.ContentSearcher, error) {
+		idx, err := ReadContentIndex(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("нет индекса содержимого %s: сканируйте с --content-index", path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return service.NewContentSearcher(idx)
+	})
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package infrastructure
+
+import "os"
+
+// fileIdentity — на этих системах inode недоступен, ключ кэша — размер и mtime
+func fileIdentity(os.FileInfo) (dev, ino uint64) {
+	return 0, 0
+}
+
+// linkCount — число жёстких ссылок недоступно: общий inode считается одной записью
+func linkCount(os.FileInfo) uint64 {
+	return 0
+}
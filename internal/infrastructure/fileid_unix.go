@@ -0,0 +1,24 @@
+//go:build unix
+
+package infrastructure
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIdentity — устройство и inode файла
+func fileIdentity(st os.FileInfo) (dev, ino uint64) {
+	if sys, ok := st.Sys().(*syscall.Stat_t); ok {
+		return uint64(sys.Dev), uint64(sys.Ino)
+	}
+	return 0, 0
+}
+
+// linkCount — число жёстких ссылок на файл; 0 — неизвестно
+func linkCount(st os.FileInfo) uint64 {
+	if sys, ok := st.Sys().(*syscall.Stat_t); ok {
+		return uint64(sys.Nlink)
+	}
+	return 0
+}
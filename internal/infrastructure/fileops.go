@@ -0,0 +1,91 @@
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SameFileLinks сравнивает два пути к файлам. shared — это один и тот же файл на диске
+// (один inode). same — это вообще одна запись в директории, увиденная под разными
+// путями: тот же путь после очистки, тот же путь после раскрытия символьных ссылок,
+// или общий inode с единственной жёсткой ссылкой (например, через bind-mount).
+// Удалять одну из «копий» можно, только если shared && !same: тогда это разные ссылки.
+func SameFileLinks(a, b string) (shared, same bool, err error) {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, false, err
+	}
+	if absA == absB {
+		return true, true, nil
+	}
+	sa, err := os.Stat(a)
+	if err != nil {
+		return false, false, err
+	}
+	sb, err := os.Stat(b)
+	if err != nil {
+		return false, false, err
+	}
+	if !os.SameFile(sa, sb) {
+		return false, false, nil
+	}
+	realA, errA := filepath.EvalSymlinks(absA)
+	realB, errB := filepath.EvalSymlinks(absB)
+	if errA != nil || errB != nil || realA == realB || linkCount(sa) < 2 {
+		return true, true, nil
+	}
+	return true, false, nil
+}
+
+// ReplaceWithHardlink атомарно заменяет target жёсткой ссылкой на keep:
+// ссылка создаётся рядом под временным именем и переименовывается поверх target
+func ReplaceWithHardlink(keep, target string) error {
+	tmp := tempSibling(target)
+	if err := os.Link(keep, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// ReplaceWithReflink атомарно заменяет target copy-on-write копией keep
+// (общие блоки на диске, но независимые файлы). Права target сохраняются.
+func ReplaceWithReflink(keep, target string) error {
+	st, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(keep)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := tempSibling(target)
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, st.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	err = cloneFile(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, target)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+func tempSibling(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".fsjson-tmp")
+}
@@ -0,0 +1,46 @@
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSameFileLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("нужны жёсткие и символьные ссылки unix")
+	}
+	dir := t.TempDir()
+	only := filepath.Join(dir, "data", "only.txt")
+	os.MkdirAll(filepath.Dir(only), 0755)
+	os.WriteFile(only, []byte("x"), 0644)
+	copyPath := filepath.Join(dir, "data", "copy.txt")
+	os.WriteFile(copyPath, []byte("x"), 0644)
+	os.Symlink(filepath.Join(dir, "data"), filepath.Join(dir, "alias"))
+
+	cases := []struct {
+		name         string
+		a, b         string
+		shared, same bool
+	}{
+		{"тот же путь", only, only, true, true},
+		{"тот же путь после очистки", only, filepath.Join(dir, "data", ".", "only.txt"), true, true},
+		{"через символьную ссылку на директорию", only, filepath.Join(dir, "alias", "only.txt"), true, true},
+		{"разные файлы", only, copyPath, false, false},
+	}
+	for _, c := range cases {
+		shared, same, err := SameFileLinks(c.a, c.b)
+		if err != nil || shared != c.shared || same != c.same {
+			t.Errorf("%s: shared=%v same=%v err=%v", c.name, shared, same, err)
+		}
+	}
+
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Link(only, link); err != nil {
+		t.Skip("жёсткие ссылки не поддерживаются:", err)
+	}
+	if shared, same, err := SameFileLinks(only, link); err != nil || !shared || same {
+		t.Errorf("жёсткая ссылка: shared=%v same=%v err=%v", shared, same, err)
+	}
+}
@@ -1,6 +1,7 @@
 package infrastructure
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -10,29 +11,22 @@ import (
 
 // WriteFinalJSONAtomic записывает дерево в файл атомарно
 func WriteFinalJSONAtomic(output string, root model.FileInfo, pretty bool) {
-	tmp := output + ".tmp"
-	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
-	if err != nil {
-		fmt.Println("Ошибка создания временного файла:", err)
-		return
-	}
-	defer f.Close()
-
-	enc := json.NewEncoder(f)
-	if pretty {
-		enc.SetIndent("", "  ")
-	}
-	if err := enc.Encode(root); err != nil {
-		fmt.Println("Ошибка записи JSON:", err)
-		_ = os.Remove(tmp)
-		return
-	}
-	_ = f.Close()
-	_ = os.Rename(tmp, output)
+	writeJSONAtomic(output, root, pretty)
 }
 
 // WriteFlatJSONAtomic записывает flat-массив
 func WriteFlatJSONAtomic(output string, arr []model.FileInfo, pretty bool) {
+	writeJSONAtomic(output, arr, pretty)
+}
+
+// WriteSnapshotJSONAtomic записывает конверт с метаданными сканирования
+func WriteSnapshotJSONAtomic(output string, snap model.Snapshot, pretty bool) {
+	snap.Schema = model.SnapshotSchema
+	snap.Version = model.SnapshotVersion
+	writeJSONAtomic(output, snap, pretty)
+}
+
+func writeJSONAtomic(output string, v any, pretty bool) {
 	tmp := output + ".tmp"
 	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 	if err != nil {
@@ -40,44 +34,62 @@ func WriteFlatJSONAtomic(output string, arr []model.FileInfo, pretty bool) {
 		return
 	}
 	defer f.Close()
+
 	enc := json.NewEncoder(f)
 	if pretty {
 		enc.SetIndent("", "  ")
 	}
-	if err := enc.Encode(arr); err != nil {
+	if err := enc.Encode(v); err != nil {
 		fmt.Println("Ошибка записи JSON:", err)
 		_ = os.Remove(tmp)
 		return
 	}
 	_ = f.Close()
 	_ = os.Rename(tmp, output)
+	if err := SignFile(output); err != nil {
+		fmt.Println("Ошибка подписи:", err)
+	}
 }
 
-// DiagnoseJSONShape выводит тип JSON (object/array)
-func DiagnoseJSONShape(path string) {
-	b := make([]byte, 1)
+// SniffJSON проверяет, начинается ли файл с JSON-объекта или массива
+func SniffJSON(path string) bool {
 	f, err := os.Open(path)
 	if err != nil {
-		fmt.Printf("🔎 diagnose: не удалось открыть %s: %v\n", path, err)
-		return
+		return false
 	}
 	defer f.Close()
+	r := bufio.NewReader(f)
 	for {
-		_, err = f.Read(b)
+		b, err := r.ReadByte()
 		if err != nil {
-			fmt.Printf("🔎 diagnose: пустой файл?\n")
-			return
+			return false
 		}
-		if b[0] != ' ' && b[0] != '\n' && b[0] != '\t' && b[0] != '\r' {
-			break
+		switch b {
+		case ' ', '\n', '\t', '\r':
+			continue
+		case '{', '[':
+			return true
+		default:
+			return false
 		}
 	}
-	switch b[0] {
-	case '{':
-		fmt.Println("🔎 diagnose: OBJECT (дерево)")
-	case '[':
-		fmt.Println("🔎 diagnose: ARRAY (flat)")
-	default:
-		fmt.Printf("🔎 diagnose: неожиданный байт: %q\n", b[0])
+}
+
+// DiagnoseJSONShape выводит формат JSON (конверт/дерево/flat)
+func DiagnoseJSONShape(path string) {
+	meta, shape, err := ReadSnapshotMeta(path)
+	if err != nil {
+		fmt.Printf("🔎 diagnose: %v\n", err)
+		return
+	}
+	kind := "OBJECT (дерево)"
+	if shape == ShapeFlat {
+		kind = "ARRAY (flat)"
+	}
+	if meta.Schema != "" {
+		fmt.Printf("🔎 diagnose: ENVELOPE v%d, %s | %s | файлов: %d, папок: %d, ошибок: %d\n",
+			meta.Version, kind, meta.RootPath, meta.Counts.Files, meta.Counts.Dirs, meta.Errors.Total)
+		return
 	}
+	fmt.Println("🔎 diagnose:", kind)
 }
@@ -0,0 +1,275 @@
+package infrastructure
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+)
+
+// jsonFieldIndex — индексы полей структуры по json-имени (в нижнем регистре)
+func jsonFieldIndex(t reflect.Type) map[string]int {
+	m := make(map[string]int)
+	for _, f := range jsonFields(t) {
+		m[strings.ToLower(f.Name)] = f.Index
+	}
+	return m
+}
+
+var (
+	fileInfoFields = jsonFieldIndex(reflect.TypeOf(model.FileInfo{}))
+	snapshotFields = jsonFieldIndex(reflect.TypeOf(model.Snapshot{}))
+)
+
+// Формы JSON-файла
+const (
+	ShapeTree = "tree" // FileInfo
+	ShapeFlat = "flat" // []FileInfo
+)
+
+// FileSource — потоковый источник узлов из JSON-файла
+func FileSource(path string) service.NodeSource {
+	return func(visit service.NodeVisitor) error {
+		return StreamJSONNodes(path, visit)
+	}
+}
+
+// StreamJSONNodes потоково обходит узлы JSON-файла (дерево FileInfo, flat []FileInfo
+// или конверт Snapshot), не загружая файл целиком в память. Узлы передаются в visit
+// по одному; ErrSkipChildren пропускает потомков узла, ErrStopWalk прекращает чтение.
+func StreamJSONNodes(path string, visit service.NodeVisitor) error {
+	_, _, err := streamSnapshot(path, visit)
+	return err
+}
+
+// ReadSnapshotMeta читает только метаданные конверта, не разбирая дерево.
+// Для файлов старого формата (голый FileInfo или []FileInfo) Schema пустая.
+func ReadSnapshotMeta(path string) (model.Snapshot, string, error) {
+	return streamSnapshot(path, func(*model.FileInfo, int) error {
+		return service.ErrStopWalk
+	})
+}
+
+// streamSnapshot — общий разбор всех поддерживаемых форм файла.
+// Возвращает метаданные конверта (если он есть) и форму данных.
+func streamSnapshot(path string, visit service.NodeVisitor) (model.Snapshot, string, error) {
+	var meta model.Snapshot
+	f, err := OpenVerified(path)
+	if err != nil {
+		return meta, "", err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReaderSize(f, 1<<20))
+	tok, err := dec.Token()
+	if err != nil {
+		return meta, "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	shape := ShapeTree
+	switch tok {
+	case json.Delim('{'):
+		if !dec.More() {
+			break
+		}
+		keyTok, terr := dec.Token()
+		if terr != nil {
+			return meta, shape, fmt.Errorf("%s: %w", path, terr)
+		}
+		key, _ := keyTok.(string)
+		if strings.EqualFold(key, "Schema") {
+			shape, err = streamEnvelope(dec, &meta, visit)
+		} else {
+			err = streamNodeFrom(dec, key, 0, visit, false)
+		}
+	case json.Delim('['):
+		shape = ShapeFlat
+		err = streamArray(dec, 0, visit, false)
+	default:
+		err = fmt.Errorf("ожидался объект или массив, получено %v", tok)
+	}
+	if err != nil && err != service.ErrStopWalk {
+		return meta, shape, fmt.Errorf("%s: %w", path, err)
+	}
+	return meta, shape, nil
+}
+
+// streamEnvelope читает конверт; '{' и ключ "Schema" уже прочитаны
+func streamEnvelope(dec *json.Decoder, meta *model.Snapshot, visit service.NodeVisitor) (string, error) {
+	shape := ShapeTree
+	val := reflect.ValueOf(meta).Elem()
+	key := "Schema"
+	for {
+		switch strings.ToLower(key) {
+		case "tree":
+			tok, err := dec.Token()
+			if err != nil {
+				return shape, err
+			}
+			if tok == json.Delim('{') {
+				if err := streamNode(dec, 0, visit, false); err != nil {
+					return shape, err
+				}
+			} else if tok != nil {
+				return shape, fmt.Errorf("Tree: ожидался объект, получено %v", tok)
+			}
+		case "items":
+			shape = ShapeFlat
+			tok, err := dec.Token()
+			if err != nil {
+				return shape, err
+			}
+			if tok == json.Delim('[') {
+				if err := streamArray(dec, 0, visit, false); err != nil {
+					return shape, err
+				}
+			} else if tok != nil {
+				return shape, fmt.Errorf("Items: ожидался массив, получено %v", tok)
+			}
+		default:
+			var target any = new(json.RawMessage)
+			if i, ok := snapshotFields[strings.ToLower(key)]; ok {
+				target = val.Field(i).Addr().Interface()
+			}
+			if err := dec.Decode(target); err != nil {
+				return shape, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+
+		if !dec.More() {
+			break
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return shape, err
+		}
+		key, _ = tok.(string)
+	}
+	_, err := dec.Token() // '}'
+	return shape, err
+}
+
+// streamArray читает массив узлов; открывающая '[' уже прочитана
+func streamArray(dec *json.Decoder, depth int, visit service.NodeVisitor, skip bool) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok != json.Delim('{') {
+			return fmt.Errorf("ожидался объект узла, получено %v", tok)
+		}
+		if err := streamNode(dec, depth, visit, skip); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token() // ']'
+	return err
+}
+
+// streamNode читает один узел; открывающая '{' уже прочитана.
+// Узел передаётся в visit перед потомками, поэтому Children должно быть последним
+// полем узла (так пишет и сам fsjson): поле FileInfo после Children — ошибка,
+// иначе фильтры увидели бы узел без него. Неизвестные поля пропускаются где угодно.
+// При skip узел и его потомки читаются, но не передаются.
+func streamNode(dec *json.Decoder, depth int, visit service.NodeVisitor, skip bool) error {
+	return streamNodeFrom(dec, "", depth, visit, skip)
+}
+
+// streamNodeFrom — как streamNode, но первый ключ объекта уже прочитан
+// (нужно при определении формата по первому ключу)
+func streamNodeFrom(dec *json.Decoder, key string, depth int, visit service.NodeVisitor, skip bool) error {
+	var node model.FileInfo
+	val := reflect.ValueOf(&node).Elem()
+	visited := skip
+	childrenRead := false
+
+	for key != "" || dec.More() {
+		if key == "" {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ = tok.(string)
+		}
+
+		if strings.EqualFold(key, "Children") {
+			childrenRead = true
+			skipKids := skip
+			if !visited {
+				visited = true
+				if err := visit(&node, depth); err == service.ErrSkipChildren {
+					skipKids = true
+				} else if err != nil {
+					return err
+				}
+			}
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if tok != nil && tok != json.Delim('[') { // "Children": null допустим
+				return fmt.Errorf("Children: ожидался массив, получено %v", tok)
+			}
+			if tok != nil {
+				if err := streamArray(dec, depth+1, visit, skipKids); err != nil {
+					return err
+				}
+			}
+		} else if i, ok := fileInfoFields[strings.ToLower(key)]; ok {
+			if childrenRead {
+				return fmt.Errorf("%s: поле после Children (узел %q) — потоковое чтение требует Children последним", key, node.FullPathOrig)
+			}
+			if err := dec.Decode(val.Field(i).Addr().Interface()); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		} else {
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return err
+			}
+		}
+		key = ""
+	}
+	if _, err := dec.Token(); err != nil { // '}'
+		return err
+	}
+
+	if !visited {
+		if err := visit(&node, depth); err != nil && err != service.ErrSkipChildren {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadSnapshot читает файл любого поддерживаемого формата целиком:
+// дерево попадает в Tree, flat-массив — в Items
+func LoadSnapshot(path string) (model.Snapshot, error) {
+	var snap model.Snapshot
+	var shape string
+	roots, err := service.CollectRoots(func(visit service.NodeVisitor) error {
+		var serr error
+		snap, shape, serr = streamSnapshot(path, visit)
+		return serr
+	})
+	if err != nil {
+		return snap, err
+	}
+	if shape == ShapeFlat {
+		snap.Items = roots
+	} else if len(roots) > 0 {
+		snap.Tree = &roots[0]
+	}
+	return snap, nil
+}
+
+// ReadTreeJSON читает JSON-файл любого поддерживаемого формата в дерево,
+// не держа в памяти исходные байты файла
+func ReadTreeJSON(path string) (model.FileInfo, error) {
+	return service.BuildTreeFrom(FileSource(path))
+}
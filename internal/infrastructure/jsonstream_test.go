@@ -0,0 +1,188 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+)
+
+func testTree() model.FileInfo {
+	return model.FileInfo{
+		IsDir: true, FullName: "root", FullPath: "/root", SizeBytes: 30, ChildCount: 2,
+		Children: []model.FileInfo{
+			{
+				IsDir: true, FullName: "sub", FullPath: "/root/sub", ParentDir: "/root", SizeBytes: 10, ChildCount: 1,
+				Children: []model.FileInfo{
+					{FullName: "a.txt", FullPath: "/root/sub/a.txt", ParentDir: "/root/sub", SizeBytes: 10, Md5: "aa"},
+				},
+			},
+			{FullName: "b.txt", FullPath: "/root/b.txt", ParentDir: "/root", SizeBytes: 20, Md5: "bb"},
+		},
+	}
+}
+
+func TestStreamJSONNodes_RoundTrip(t *testing.T) {
+	tree := testTree()
+	path := filepath.Join(t.TempDir(), "tree.json")
+	data, _ := json.Marshal(tree)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadTreeJSON(path)
+	if err != nil {
+		t.Fatalf("ошибка чтения: %v", err)
+	}
+	if !reflect.DeepEqual(got, tree) {
+		t.Fatalf("дерево после потокового чтения отличается:\n%+v\n%+v", got, tree)
+	}
+}
+
+func TestStreamJSONNodes_SkipAndStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.json")
+	data, _ := json.Marshal(testTree())
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	err := StreamJSONNodes(path, func(n *model.FileInfo, depth int) error {
+		seen = append(seen, n.FullName)
+		if n.FullName == "sub" {
+			return service.ErrSkipChildren
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"root", "sub", "b.txt"}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("ожидалось %v, получено %v", want, seen)
+	}
+
+	seen = nil
+	_ = StreamJSONNodes(path, func(n *model.FileInfo, depth int) error {
+		seen = append(seen, n.FullName)
+		return service.ErrStopWalk
+	})
+	if len(seen) != 1 {
+		t.Fatalf("ожидалась остановка после первого узла, получено %v", seen)
+	}
+}
+
+func TestStreamJSONNodes_Flat(t *testing.T) {
+	flat := service.FlattenTree(testTree())
+	path := filepath.Join(t.TempDir(), "flat.json")
+	data, _ := json.Marshal(flat)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadTreeJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FullName != "root" || len(got.Children) != 2 || got.SizeBytes != 30 {
+		t.Fatalf("неверно собрано дерево из flat: %+v", got)
+	}
+}
+
+func TestLoadSnapshot_Envelope(t *testing.T) {
+	tree := testTree()
+	path := filepath.Join(t.TempDir(), "snap.json")
+	WriteSnapshotJSONAtomic(path, model.Snapshot{RootPath: "/root", Tree: &tree}, false)
+
+	meta, shape, err := ReadSnapshotMeta(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Schema != model.SnapshotSchema || meta.RootPath != "/root" || shape != ShapeTree {
+		t.Fatalf("неверные метаданные конверта: %+v (%s)", meta, shape)
+	}
+
+	snap, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Tree == nil || !reflect.DeepEqual(*snap.Tree, tree) {
+		t.Fatalf("дерево из конверта отличается: %+v", snap.Tree)
+	}
+}
+
+func TestLoadSnapshot_TruncatedEnvelope(t *testing.T) {
+	tree := testTree()
+	path := filepath.Join(t.TempDir(), "snap.json")
+	WriteSnapshotJSONAtomic(path, model.Snapshot{RootPath: "/root", Tree: &tree}, false)
+	data, _ := os.ReadFile(path)
+
+	for _, cut := range []int{len(data) / 3, len(data) - 3} {
+		if err := os.WriteFile(path, data[:cut], 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadSnapshot(path); err == nil {
+			t.Errorf("обрезанный на %d из %d байт конверт прочитан без ошибки", cut, len(data))
+		}
+		if err := StreamJSONNodes(path, func(*model.FileInfo, int) error { return nil }); err == nil {
+			t.Errorf("потоковое чтение обрезанного на %d байт конверта без ошибки", cut)
+		}
+	}
+}
+
+func TestStreamJSONNodes_FlatVisitsOnce(t *testing.T) {
+	// FlattenTree убирает Children у элементов: иначе потоковое чтение flat-массива
+	// прошло бы каждое поддерево ещё раз изнутри его родителя
+	path := filepath.Join(t.TempDir(), "flat.json")
+	data, _ := json.Marshal(service.FlattenTree(testTree()))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var seen []string
+	err := StreamJSONNodes(path, func(n *model.FileInfo, depth int) error {
+		seen = append(seen, n.FullName)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"root", "sub", "a.txt", "b.txt"}; !reflect.DeepEqual(seen, want) {
+		t.Fatalf("ожидалось %v, получено %v", want, seen)
+	}
+}
+
+func TestStreamJSONNodes_FieldAfterChildren(t *testing.T) {
+	// узел уже передан в visit, когда встречено поле после Children: вместо
+	// молча потерянного значения — ошибка; неизвестные поля после Children допустимы
+	path := filepath.Join(t.TempDir(), "tree.json")
+	for _, tc := range []struct {
+		json string
+		ok   bool
+	}{
+		{`{"FullName":"root","IsDir":true,"Children":[{"FullName":"a"}],"Note":"x"}`, true},
+		{`{"FullName":"root","IsDir":true,"Children":[{"FullName":"a"}],"SizeBytes":5}`, false},
+		{`{"FullName":"root","IsDir":true,"Children":[{"FullName":"a","Children":null,"Md5":"aa"}]}`, false},
+	} {
+		if err := os.WriteFile(path, []byte(tc.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := StreamJSONNodes(path, func(*model.FileInfo, int) error { return nil })
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: ошибка %v", tc.json, err)
+		}
+	}
+
+	// --validate сообщает о том же
+	_, issues, _ := ValidateJSON([]byte(`{"FullName":"root","IsDir":true,"FullPath":"/r","ParentDir":"/","Children":[],"SizeBytes":0}`))
+	found := false
+	for _, is := range issues {
+		found = found || is.Path == "$.SizeBytes" && strings.Contains(is.Message, "после Children")
+	}
+	if !found {
+		t.Errorf("нет нарушения порядка полей: %v", issues)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package infrastructure
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone — ioctl FICLONE из linux/fs.h (_IOW(0x94, 9, int))
+const ficlone = 0x40049409
+
+// cloneFile делает dst copy-on-write копией src (btrfs, xfs, bcachefs...)
+func cloneFile(dst, src *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dst.Fd(), ficlone, src.Fd())
+	if errno != 0 {
+		return &os.PathError{Op: "FICLONE", Path: dst.Name(), Err: errno}
+	}
+	return nil
+}
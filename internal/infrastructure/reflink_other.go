@@ -0,0 +1,13 @@
+//go:build !linux
+
+package infrastructure
+
+import (
+	"errors"
+	"os"
+)
+
+// cloneFile — reflink поддерживается только в Linux (FICLONE)
+func cloneFile(dst, src *os.File) error {
+	return errors.New("reflink поддерживается только в Linux")
+}
@@ -0,0 +1,125 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"fsjson/internal/domain/model"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// jsonField — поле структуры так, как оно выглядит в JSON
+type jsonField struct {
+	Name      string
+	Index     int
+	OmitEmpty bool
+}
+
+// jsonFields перечисляет сериализуемые поля структуры
+func jsonFields(t reflect.Type) []jsonField {
+	var out []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "" {
+			name = f.Name
+		}
+		omit := false
+		for _, p := range parts[1:] {
+			omit = omit || p == "omitempty"
+		}
+		out = append(out, jsonField{Name: name, Index: i, OmitEmpty: omit})
+	}
+	return out
+}
+
+// GenerateJSONSchema строит JSON Schema (draft 2020-12) для всех форматов снимка:
+// конверт Snapshot, дерево FileInfo и flat-массив []FileInfo.
+// Схема выводится из Go-типов модели, поэтому не расходится с кодом.
+func GenerateJSONSchema() map[string]any {
+	defs := map[string]any{}
+	snapshot := schemaFor(reflect.TypeOf(model.Snapshot{}), defs)
+	tree := schemaFor(reflect.TypeOf(model.FileInfo{}), defs)
+
+	// конверт опознаётся по константе в первом поле
+	snapDef := defs["Snapshot"].(map[string]any)
+	snapDef["properties"].(map[string]any)["Schema"] = map[string]any{"const": model.SnapshotSchema}
+
+	// ChildCount — число элементов на диске: исключённые и нечитаемые в Children не попадают
+	infoDef := defs["FileInfo"].(map[string]any)
+	infoDef["properties"].(map[string]any)["ChildCount"] = map[string]any{
+		"type":        "integer",
+		"minimum":     0,
+		"description": "Число элементов директории на диске, не меньше длины Children",
+	}
+
+	return map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "fsjson snapshot",
+		"oneOf": []any{
+			snapshot,
+			tree,
+			map[string]any{"type": "array", "items": tree},
+		},
+		"$defs": defs,
+	}
+}
+
+// MarshalJSONSchema возвращает схему в виде отформатированного JSON
+func MarshalJSONSchema() []byte {
+	b, _ := json.MarshalIndent(GenerateJSONSchema(), "", "  ")
+	return append(b, '\n')
+}
+
+func schemaFor(t reflect.Type, defs map[string]any) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": []string{"array", "null"}, "items": schemaFor(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": schemaFor(t.Elem(), defs)}
+	case reflect.Pointer:
+		return map[string]any{"anyOf": []any{schemaFor(t.Elem(), defs), map[string]any{"type": "null"}}}
+	case reflect.Struct:
+		ref := map[string]any{"$ref": "#/$defs/" + t.Name()}
+		if _, ok := defs[t.Name()]; ok {
+			return ref
+		}
+		def := map[string]any{"type": "object", "additionalProperties": false}
+		defs[t.Name()] = def // до обхода полей: FileInfo ссылается сам на себя
+		props := map[string]any{}
+		required := []string{}
+		for _, f := range jsonFields(t) {
+			props[f.Name] = schemaFor(t.Field(f.Index).Type, defs)
+			if !f.OmitEmpty {
+				required = append(required, f.Name)
+			}
+		}
+		def["properties"] = props
+		def["required"] = required
+		return ref
+	}
+	return map[string]any{}
+}
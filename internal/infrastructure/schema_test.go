@@ -0,0 +1,19 @@
+package infrastructure
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// Опубликованная схема должна совпадать с генерируемой из модели.
+// Обновить: go run ./cmd/fsjson --print-schema > schema/fsjson.schema.json
+func TestPublishedSchemaUpToDate(t *testing.T) {
+	published, err := os.ReadFile("../../schema/fsjson.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(published, MarshalJSONSchema()) {
+		t.Fatal("schema/fsjson.schema.json устарела — перегенерируйте через --print-schema")
+	}
+}
@@ -0,0 +1,236 @@
+package infrastructure
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// signatureScheme — заголовок файла подписи: Ed25519ph поверх SHA-512 содержимого,
+// чтобы подписывать большие файлы потоково
+const signatureScheme = "ed25519ph-sha512"
+
+var (
+	signKey    ed25519.PrivateKey // --sign-key: подписывать сохраняемые файлы
+	trustedKey ed25519.PublicKey  // --verify-signature: требовать подпись при чтении
+	verified   sync.Map           // path -> fileStamp уже проверенных файлов
+)
+
+// fileStamp — состояние файла на момент проверки подписи. mtime легко подделать,
+// поэтому в ключ входят и устройство с inode: замена файла другим меняет их.
+type fileStamp struct {
+	size     int64
+	mtime    time.Time
+	dev, ino uint64
+}
+
+func stampOf(st os.FileInfo) fileStamp {
+	stamp := fileStamp{size: st.Size(), mtime: st.ModTime()}
+	stamp.dev, stamp.ino = fileIdentity(st)
+	return stamp
+}
+
+// SignaturePath — путь к detached-подписи файла
+func SignaturePath(path string) string {
+	return path + ".sig"
+}
+
+// InitSigner загружает приватный ключ (PEM, PKCS#8) для подписи сохраняемых файлов
+func InitSigner(keyPath string) error {
+	block, err := readPEM(keyPath)
+	if err != nil {
+		return err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", keyPath, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return fmt.Errorf("%s: ожидался ключ ed25519, получен %T", keyPath, key)
+	}
+	signKey = priv
+	return nil
+}
+
+// InitSignatureVerifier загружает доверенный публичный ключ (PEM, PKIX):
+// после этого все режимы чтения требуют корректную подпись файла
+func InitSignatureVerifier(pubPath string) error {
+	block, err := readPEM(pubPath)
+	if err != nil {
+		return err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", pubPath, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return fmt.Errorf("%s: ожидался ключ ed25519, получен %T", pubPath, key)
+	}
+	trustedKey = pub
+	return nil
+}
+
+// GenerateSigningKey создаёт пару ключей: <prefix>.key (приватный) и <prefix>.pub
+func GenerateSigningKey(prefix string) error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(prefix+".key", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(prefix+".pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644)
+}
+
+// SignFile пишет detached-подпись файла, если задан --sign-key
+func SignFile(path string) error {
+	if signKey == nil {
+		return nil
+	}
+	digest, err := fileSHA512(path)
+	if err != nil {
+		return err
+	}
+	sig, err := signKey.Sign(nil, digest, &ed25519.Options{Hash: crypto.SHA512})
+	if err != nil {
+		return err
+	}
+	pub := signKey.Public().(ed25519.PublicKey)
+	content := fmt.Sprintf("fsjson-signature: %s\nkey: %s\nsig: %s\n", signatureScheme,
+		base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(sig))
+
+	sigPath := SignaturePath(path)
+	if err := os.WriteFile(sigPath+".tmp", []byte(content), 0644); err != nil {
+		return err
+	}
+	return os.Rename(sigPath+".tmp", sigPath)
+}
+
+// VerifyFileSignature проверяет detached-подпись файла доверенным ключом
+func VerifyFileSignature(path string, pub ed25519.PublicKey) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return verifySignature(path, f, pub)
+}
+
+// verifySignature проверяет подпись path по содержимому r
+func verifySignature(path string, r io.Reader, pub ed25519.PublicKey) error {
+	f, err := os.Open(SignaturePath(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s: нет подписи (%s)", path, SignaturePath(path))
+		}
+		return err
+	}
+	defer f.Close()
+
+	fields := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if k, v, ok := strings.Cut(sc.Text(), ":"); ok {
+			fields[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if fields["fsjson-signature"] != signatureScheme {
+		return fmt.Errorf("%s: неизвестная схема подписи %q", path, fields["fsjson-signature"])
+	}
+	sig, err := base64.StdEncoding.DecodeString(fields["sig"])
+	if err != nil {
+		return fmt.Errorf("%s: повреждена подпись: %w", path, err)
+	}
+	if key, _ := base64.StdEncoding.DecodeString(fields["key"]); !pub.Equal(ed25519.PublicKey(key)) {
+		return fmt.Errorf("%s: подписан недоверенным ключом", path)
+	}
+
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	if err := ed25519.VerifyWithOptions(pub, h.Sum(nil), sig, &ed25519.Options{Hash: crypto.SHA512}); err != nil {
+		return fmt.Errorf("%s: подпись не совпадает — файл изменён после подписи", path)
+	}
+	return nil
+}
+
+// OpenVerified открывает файл для чтения; если задан --verify-signature, сначала
+// проверяет подпись по этому же дескриптору и перематывает его в начало. Так
+// читается именно проверенный файл: подменить его между проверкой и чтением нельзя.
+// Повторные открытия того же неизменённого файла не перепроверяются.
+func OpenVerified(path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if err != nil || trustedKey == nil {
+		return f, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	stamp := stampOf(st)
+	if v, ok := verified.Load(path); ok && v.(fileStamp) == stamp {
+		return f, nil
+	}
+	if err := verifySignature(path, f, trustedKey); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	verified.Store(path, stamp)
+	return f, nil
+}
+
+func fileSHA512(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha512.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New(path + ": не найден PEM-блок")
+	}
+	return block, nil
+}
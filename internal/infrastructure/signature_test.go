@@ -0,0 +1,80 @@
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignature_SignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	prefix := filepath.Join(dir, "k")
+	if err := GenerateSigningKey(prefix); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitSigner(prefix + ".key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitSignatureVerifier(prefix + ".pub"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { signKey, trustedKey = nil, nil }()
+
+	path := filepath.Join(dir, "tree.json")
+	WriteFinalJSONAtomic(path, testTree(), false)
+	if _, err := os.Stat(SignaturePath(path)); err != nil {
+		t.Fatalf("подпись не записана: %v", err)
+	}
+	if _, err := ReadTreeJSON(path); err != nil {
+		t.Fatalf("подписанный файл не прочитан: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	data[len(data)-2] = ' '
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyFileSignature(path, trustedKey); err == nil {
+		t.Fatal("изменённый файл прошёл проверку подписи")
+	}
+
+	unsigned := filepath.Join(dir, "unsigned.json")
+	os.WriteFile(unsigned, []byte("{}"), 0644)
+	if _, err := OpenVerified(unsigned); err == nil {
+		t.Fatal("неподписанный файл прошёл проверку")
+	}
+}
+
+func TestOpenVerified_ReplacedWithSameStamp(t *testing.T) {
+	dir := t.TempDir()
+	prefix := filepath.Join(dir, "k")
+	if err := GenerateSigningKey(prefix); err != nil {
+		t.Fatal(err)
+	}
+	InitSigner(prefix + ".key")
+	InitSignatureVerifier(prefix + ".pub")
+	defer func() { signKey, trustedKey = nil, nil }()
+
+	path := filepath.Join(dir, "tree.json")
+	WriteFinalJSONAtomic(path, testTree(), false)
+	f, err := OpenVerified(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// подмена файлом того же размера с тем же mtime: раньше кэш её пропускал
+	st, _ := os.Stat(path)
+	data, _ := os.ReadFile(path)
+	data[len(data)-2] = ' '
+	forged := filepath.Join(dir, "forged")
+	os.WriteFile(forged, data, 0644)
+	os.Chtimes(forged, st.ModTime(), st.ModTime())
+	if err := os.Rename(forged, path); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := OpenVerified(path); err == nil {
+		f.Close()
+		t.Fatal("подменённый файл прошёл проверку по кэшу")
+	}
+}
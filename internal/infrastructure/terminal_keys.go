@@ -0,0 +1,106 @@
+package infrastructure
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// Клавиши, которые приходят escape-последовательностями или управляющими символами
+const (
+	KeyNone = iota
+	KeyRune // обычный символ, см. Key.Rune
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyEnter
+	KeyBackspace
+	KeyEscape
+	KeyCtrlC
+)
+
+// Key — нажатие клавиши
+type Key struct {
+	Code int
+	Rune rune
+}
+
+// escapeKeys — последовательности xterm/VT100 (и их варианты в разных терминалах)
+var escapeKeys = map[string]int{
+	"\x1b[A": KeyUp, "\x1b[B": KeyDown, "\x1b[C": KeyRight, "\x1b[D": KeyLeft,
+	"\x1bOA": KeyUp, "\x1bOB": KeyDown, "\x1bOC": KeyRight, "\x1bOD": KeyLeft,
+	"\x1b[5~": KeyPageUp, "\x1b[6~": KeyPageDown,
+	"\x1b[H": KeyHome, "\x1b[F": KeyEnd, "\x1bOH": KeyHome, "\x1bOF": KeyEnd,
+	"\x1b[1~": KeyHome, "\x1b[4~": KeyEnd, "\x1b[7~": KeyHome, "\x1b[8~": KeyEnd,
+}
+
+// ParseKey разбирает первое нажатие в буфере и возвращает, сколько байт оно заняло
+// (0 — буфер пуст или символ UTF-8 пришёл не целиком). Одиночный ESC — KeyEscape,
+// неизвестная последовательность пропускается целиком как KeyNone.
+func ParseKey(b []byte) (Key, int) {
+	if len(b) == 0 {
+		return Key{}, 0
+	}
+	switch c := b[0]; {
+	case c == 0x1b:
+		if len(b) == 1 {
+			return Key{Code: KeyEscape}, 1
+		}
+		for seq, code := range escapeKeys {
+			if bytes.HasPrefix(b, []byte(seq)) {
+				return Key{Code: code}, len(seq)
+			}
+		}
+		if b[1] != '[' && b[1] != 'O' {
+			return Key{Code: KeyEscape}, 1
+		}
+		// CSI: параметры и завершающий символ @..~
+		n := 2
+		for n < len(b) && (b[n] < 0x40 || b[n] > 0x7e) {
+			n++
+		}
+		return Key{}, min(n+1, len(b))
+	case c == '\r' || c == '\n':
+		return Key{Code: KeyEnter}, 1
+	case c == 0x7f || c == 0x08:
+		return Key{Code: KeyBackspace}, 1
+	case c == 0x03:
+		return Key{Code: KeyCtrlC}, 1
+	case c < 0x20:
+		return Key{}, 1
+	}
+	if !utf8.FullRune(b) {
+		return Key{}, 0
+	}
+	r, n := utf8.DecodeRune(b)
+	return Key{Code: KeyRune, Rune: r}, n
+}
+
+// ReadKeys читает нажатия из r и отправляет их в keys, пока чтение не закончится ошибкой
+func ReadKeys(r io.Reader, keys chan<- Key) error {
+	buf := make([]byte, 0, 256)
+	chunk := make([]byte, 128)
+	for {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		for len(buf) > 0 {
+			k, used := ParseKey(buf)
+			if used == 0 {
+				break
+			}
+			buf = buf[used:]
+			if k.Code != KeyNone {
+				keys <- k
+			}
+		}
+		buf = append(buf[:0:0], buf...)
+		if err != nil {
+			return err
+		}
+	}
+}
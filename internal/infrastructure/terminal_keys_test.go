@@ -0,0 +1,57 @@
+package infrastructure
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Key
+		used int
+	}{
+		{"\x1b[A", Key{Code: KeyUp}, 3},
+		{"\x1bOB", Key{Code: KeyDown}, 3},
+		{"\x1b[6~q", Key{Code: KeyPageDown}, 4},
+		{"\x1b", Key{Code: KeyEscape}, 1},
+		{"\x1bq", Key{Code: KeyEscape}, 1}, // Alt+q — ESC и отдельно q
+		{"\x1b[1;5Cx", Key{}, 6},           // неизвестная CSI пропускается целиком
+		{"\r", Key{Code: KeyEnter}, 1},
+		{"\x7f", Key{Code: KeyBackspace}, 1},
+		{"\x03", Key{Code: KeyCtrlC}, 1},
+		{"я", Key{Code: KeyRune, Rune: 'я'}, 2},
+		{"я"[:1], Key{}, 0}, // символ пришёл не целиком — ждём остальное
+		{"", Key{}, 0},
+	}
+	for _, c := range cases {
+		k, used := ParseKey([]byte(c.in))
+		if k != c.want || used != c.used {
+			t.Errorf("%q: %+v/%d, ожидалось %+v/%d", c.in, k, used, c.want, c.used)
+		}
+	}
+}
+
+func TestReadKeys(t *testing.T) {
+	// UTF-8 символ разрезан между чтениями
+	r := io.MultiReader(strings.NewReader("q\x1b[B\xd1"), strings.NewReader("\x8f\r"))
+	keys := make(chan Key, 10)
+	if err := ReadKeys(r, keys); err != io.EOF {
+		t.Fatalf("ошибка чтения: %v", err)
+	}
+	close(keys)
+	var got []Key
+	for k := range keys {
+		got = append(got, k)
+	}
+	want := []Key{{Code: KeyRune, Rune: 'q'}, {Code: KeyDown}, {Code: KeyRune, Rune: 'я'}, {Code: KeyEnter}}
+	if len(got) != len(want) {
+		t.Fatalf("%+v, ожидалось %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%d: %+v, ожидалось %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package infrastructure
+
+import (
+	"errors"
+	"os"
+)
+
+// Terminal — сырой режим терминала есть только в Linux, macOS и BSD
+type Terminal struct{}
+
+// OpenTerminal — на этой платформе терминальный интерфейс не поддерживается
+func OpenTerminal() (*Terminal, error) {
+	return nil, errors.New("терминальный интерфейс поддерживается в Linux, macOS и BSD")
+}
+
+func (t *Terminal) Restore() error                    { return nil }
+func (t *Terminal) Size() (cols, rows int, err error) { return 80, 24, nil }
+func (t *Terminal) Resized() <-chan os.Signal         { return nil }
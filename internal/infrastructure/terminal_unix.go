@@ -0,0 +1,66 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package infrastructure
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// Terminal — терминал в «сыром» режиме: нажатия приходят сразу, без эха и построчной буферизации
+type Terminal struct {
+	fd     int
+	saved  syscall.Termios
+	resize chan os.Signal
+}
+
+// OpenTerminal переводит stdin в сырой режим; Restore возвращает прежний
+func OpenTerminal() (*Terminal, error) {
+	fd := int(os.Stdin.Fd())
+	t := &Terminal{fd: fd}
+	if err := termios(fd, ioctlReadTermios, &t.saved); err != nil {
+		return nil, fmt.Errorf("stdin — не терминал: %w", err)
+	}
+	raw := t.saved
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := termios(fd, ioctlWriteTermios, &raw); err != nil {
+		return nil, err
+	}
+	t.resize = make(chan os.Signal, 1)
+	signal.Notify(t.resize, syscall.SIGWINCH)
+	return t, nil
+}
+
+// Restore возвращает терминал в исходный режим
+func (t *Terminal) Restore() error {
+	signal.Stop(t.resize)
+	return termios(t.fd, ioctlWriteTermios, &t.saved)
+}
+
+// Size — ширина и высота терминала в символах
+func (t *Terminal) Size() (cols, rows int, err error) {
+	var ws struct{ Row, Col, X, Y uint16 }
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, uintptr(t.fd), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); e != 0 {
+		return 0, 0, e
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+// Resized — сигнал об изменении размера окна
+func (t *Terminal) Resized() <-chan os.Signal { return t.resize }
+
+func termios(fd int, req uintptr, t *syscall.Termios) error {
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(unsafe.Pointer(t))); e != 0 {
+		return e
+	}
+	return nil
+}
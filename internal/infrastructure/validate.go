@@ -0,0 +1,582 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+)
+
+// ValidateJSONFile проверяет файл снимка: сначала структуру по схеме
+// (типы полей, обязательные и неизвестные поля), затем инварианты дерева.
+// Файл читается потоково, за один проход: в памяти только текущая ветка дерева,
+// сводки прямых потомков открытых директорий и (для flat) множество путей.
+// Возвращает форму файла и найденные нарушения; error — только если файл не прочитан.
+func ValidateJSONFile(path string) (string, []service.ValidationIssue, error) {
+	f, err := OpenVerified(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	return validateJSON(f)
+}
+
+// ValidateJSON — то же для уже прочитанных данных
+func ValidateJSON(data []byte) (string, []service.ValidationIssue, error) {
+	return validateJSON(bytes.NewReader(data))
+}
+
+// Поля, по которым проверяются узлы и конверт (считаются один раз, а не на каждый узел)
+var (
+	fileInfoType         = reflect.TypeOf(model.FileInfo{})
+	snapshotType         = reflect.TypeOf(model.Snapshot{})
+	nodeSchemaFields     = fieldsByName(fileInfoType)
+	envelopeSchemaFields = fieldsByName(snapshotType)
+)
+
+// validator — потоковая проверка: issues — нарушения структуры, invariants —
+// нарушения инвариантов (показываются, только если структура корректна)
+type validator struct {
+	dec        *json.Decoder
+	issues     []service.ValidationIssue
+	invariants []service.ValidationIssue
+	counts     model.SnapshotCounts // узлы дерева — для сверки с Counts конверта
+}
+
+func validateJSON(r io.ReadSeeker) (string, []service.ValidationIssue, error) {
+	v := &validator{dec: json.NewDecoder(bufio.NewReaderSize(r, 1<<20))}
+	v.dec.UseNumber()
+
+	shape, err := v.document()
+	if err != nil {
+		if !isJSONSyntaxError(err) {
+			return "", nil, err
+		}
+		return "", []service.ValidationIssue{{Path: "$", Message: describeJSONError(r, v.dec.InputOffset(), err)}}, nil
+	}
+	if len(v.issues) > 0 {
+		return shape, v.issues, nil
+	}
+	return shape, v.invariants, nil
+}
+
+// document разбирает корень файла: дерево, flat-массив или конверт.
+// Конвертом, как и при чтении (streamSnapshot), считается объект с первым ключом Schema.
+func (v *validator) document() (string, error) {
+	tok, err := v.dec.Token()
+	if err != nil {
+		return "", err
+	}
+	switch tok {
+	case json.Delim('['):
+		return ShapeFlat, v.flatArray("$")
+	case json.Delim('{'):
+		if !v.dec.More() {
+			_, err := v.node("$", "", true)
+			return ShapeTree, err
+		}
+		keyTok, err := v.dec.Token()
+		if err != nil {
+			return ShapeTree, err
+		}
+		key, _ := keyTok.(string)
+		if key == "Schema" {
+			return v.envelope()
+		}
+		_, err = v.node("$", key, true)
+		return ShapeTree, err
+	}
+	v.fail("$", "ожидался объект или массив, получено %s", tokenKind(tok))
+	return "", nil
+}
+
+// envelope проверяет конверт; '{' и ключ "Schema" уже прочитаны
+func (v *validator) envelope() (string, error) {
+	shape := ShapeTree
+	var snap model.Snapshot
+	val := reflect.ValueOf(&snap).Elem()
+	fields := envelopeSchemaFields
+	seen := make(map[string]bool)
+	var hasTree, hasItems bool
+	schemaOK := true
+
+	for key := "Schema"; ; {
+		seen[key] = true
+		path := "$." + key
+		switch f, known := fields.byName[key]; {
+		case key == "Tree":
+			tok, err := v.dec.Token()
+			if err != nil {
+				return shape, err
+			}
+			if tok == json.Delim('{') {
+				hasTree = true
+				if _, err := v.node(path, "", true); err != nil {
+					return shape, err
+				}
+			} else if tok != nil {
+				v.fail(path, "ожидался объект FileInfo, получено %s", tokenKind(tok))
+				if err := v.skip(tok); err != nil {
+					return shape, err
+				}
+			}
+		case key == "Items":
+			tok, err := v.dec.Token()
+			if err != nil {
+				return shape, err
+			}
+			if tok == json.Delim('[') {
+				hasItems = true
+				if err := v.flatArray(path); err != nil {
+					return shape, err
+				}
+			} else if tok != nil {
+				v.fail(path, "ожидался массив, получено %s", tokenKind(tok))
+				if err := v.skip(tok); err != nil {
+					return shape, err
+				}
+			}
+		case known:
+			ok, err := v.field(path, val.Field(f.Index))
+			if err != nil {
+				return shape, err
+			}
+			if key == "Schema" {
+				schemaOK = ok
+			}
+		default:
+			v.fail(path, "неизвестное поле Snapshot")
+			if err := v.skipNext(); err != nil {
+				return shape, err
+			}
+		}
+
+		if !v.dec.More() {
+			break
+		}
+		tok, err := v.dec.Token()
+		if err != nil {
+			return shape, err
+		}
+		key, _ = tok.(string)
+	}
+	if _, err := v.dec.Token(); err != nil { // '}'
+		return shape, err
+	}
+	v.missing("$", envelopeSchemaFields, seen)
+	if schemaOK && snap.Schema != model.SnapshotSchema {
+		v.fail("$.Schema", "ожидалось %q, получено %q", model.SnapshotSchema, snap.Schema)
+	}
+
+	// инварианты: Items важнее Tree, как и при чтении
+	switch {
+	case hasItems:
+		shape = ShapeFlat
+		v.invariants = slices.DeleteFunc(v.invariants, func(is service.ValidationIssue) bool {
+			return strings.HasPrefix(is.Path, "$.Tree")
+		})
+	case !hasTree:
+		v.invariants = append(v.invariants, service.ValidationIssue{Path: "$", Message: "в конверте нет ни Tree, ни Items"})
+	default:
+		v.invariants = append(v.invariants, service.CompareCounts(snap.Counts, v.counts, "$")...)
+	}
+	return shape, nil
+}
+
+// flatArray проверяет массив узлов; '[' уже прочитана
+func (v *validator) flatArray(base string) error {
+	flat := service.NewFlatValidator(base)
+	for i := 0; v.dec.More(); i++ {
+		path := fmt.Sprintf("%s[%d]", base, i)
+		tok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok != json.Delim('{') {
+			v.fail(path, "ожидался объект FileInfo, получено %s", tokenKind(tok))
+			if err := v.skip(tok); err != nil {
+				return err
+			}
+			continue
+		}
+		var n nodeSummary
+		if n, err = v.node(path, "", false); err != nil {
+			return err
+		}
+		v.invariants = append(v.invariants, flat.Add(i, &n.FileInfo, n.children)...)
+	}
+	_, err := v.dec.Token() // ']'
+	return err
+}
+
+// nodeSummary — прочитанные поля узла (без Children) и число его потомков
+type nodeSummary struct {
+	model.FileInfo
+	children int
+}
+
+// node проверяет объект FileInfo; '{' уже прочитана, key — уже прочитанный первый ключ
+// (пусто — ещё не читался). В режиме tree проверяются инварианты дерева и считаются
+// узлы; потомки элементов flat-массива проверяются только по структуре.
+func (v *validator) node(path, key string, tree bool) (nodeSummary, error) {
+	var n nodeSummary
+	val := reflect.ValueOf(&n.FileInfo).Elem()
+	fields := nodeSchemaFields
+	seen := make(map[string]bool)
+	var children []service.DirChild
+	childrenRead := false
+
+	for key != "" || v.dec.More() {
+		if key == "" {
+			tok, err := v.dec.Token()
+			if err != nil {
+				return n, err
+			}
+			key, _ = tok.(string)
+		}
+		seen[key] = true
+		fpath := path + "." + key
+		switch f, known := fields.byName[key]; {
+		case key == "Children":
+			childrenRead = true
+			tok, err := v.dec.Token()
+			if err != nil {
+				return n, err
+			}
+			if tok == json.Delim('[') {
+				for i := 0; v.dec.More(); i++ {
+					cpath := fmt.Sprintf("%s[%d]", fpath, i)
+					ctok, err := v.dec.Token()
+					if err != nil {
+						return n, err
+					}
+					if ctok != json.Delim('{') {
+						v.fail(cpath, "ожидался объект FileInfo, получено %s", tokenKind(ctok))
+						if err := v.skip(ctok); err != nil {
+							return n, err
+						}
+						continue
+					}
+					c, err := v.node(cpath, "", tree)
+					if err != nil {
+						return n, err
+					}
+					children = append(children, service.DirChild{Path: cpath, ParentDir: c.ParentDir, SizeBytes: c.SizeBytes})
+				}
+				if _, err := v.dec.Token(); err != nil { // ']'
+					return n, err
+				}
+			} else if tok != nil {
+				v.fail(fpath, "ожидался массив, получено %s", tokenKind(tok))
+				if err := v.skip(tok); err != nil {
+					return n, err
+				}
+			}
+		case known:
+			if childrenRead {
+				v.fail(fpath, "поле после Children: при потоковом чтении Children должно быть последним")
+			}
+			if _, err := v.field(fpath, val.Field(f.Index)); err != nil {
+				return n, err
+			}
+		default:
+			v.fail(fpath, "неизвестное поле FileInfo")
+			if err := v.skipNext(); err != nil {
+				return n, err
+			}
+		}
+		key = ""
+	}
+	if _, err := v.dec.Token(); err != nil { // '}'
+		return n, err
+	}
+	v.missing(path, nodeSchemaFields, seen)
+
+	n.children = len(children)
+	if tree {
+		v.invariants = append(v.invariants, service.ValidateNode(&n.FileInfo, path, children)...)
+		if n.IsDir {
+			v.counts.Dirs++
+		} else {
+			v.counts.Files++
+			v.counts.Bytes += n.SizeBytes
+		}
+	}
+	return n, nil
+}
+
+// field проверяет значение поля по типу dst и, если оно корректно, записывает его в dst
+func (v *validator) field(path string, dst reflect.Value) (bool, error) {
+	var raw json.RawMessage
+	if err := v.dec.Decode(&raw); err != nil {
+		return false, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return false, err
+	}
+	before := len(v.issues)
+	checkJSONValue(path, value, dst.Type(), &v.issues)
+	if len(v.issues) > before {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, dst.Addr().Interface()); err != nil {
+		v.fail(path, "%s", strings.TrimPrefix(err.Error(), "json: "))
+		return false, nil
+	}
+	return true, nil
+}
+
+// missing отмечает отсутствующие обязательные поля объекта
+func (v *validator) missing(path string, fields jsonFieldSet, seen map[string]bool) {
+	for _, f := range fields.list {
+		if !seen[f.Name] && !f.OmitEmpty {
+			v.fail(path+"."+f.Name, "отсутствует обязательное поле")
+		}
+	}
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	v.issues = append(v.issues, service.ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// skipNext пропускает следующее значение, не загружая его целиком
+func (v *validator) skipNext() error {
+	tok, err := v.dec.Token()
+	if err != nil {
+		return err
+	}
+	return v.skip(tok)
+}
+
+// skip пропускает значение, первый токен которого уже прочитан
+func (v *validator) skip(tok json.Token) error {
+	for depth := 0; ; {
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+		var err error
+		if tok, err = v.dec.Token(); err != nil {
+			return err
+		}
+	}
+}
+
+// jsonFieldSet — сериализуемые поля структуры в порядке объявления и по JSON-имени
+type jsonFieldSet struct {
+	list   []jsonField
+	byName map[string]jsonField
+}
+
+func fieldsByName(t reflect.Type) jsonFieldSet {
+	set := jsonFieldSet{list: jsonFields(t), byName: make(map[string]jsonField)}
+	for _, f := range set.list {
+		set.byName[f.Name] = f
+	}
+	return set
+}
+
+// tokenKind — название JSON-типа по первому токену значения
+func tokenKind(tok json.Token) string {
+	switch tok {
+	case json.Delim('{'):
+		return "объект"
+	case json.Delim('['):
+		return "массив"
+	}
+	return jsonKind(tok)
+}
+
+// checkJSONValue сверяет разобранное значение с Go-типом модели по тем же правилам,
+// по которым строится схема в GenerateJSONSchema
+func checkJSONValue(path string, v any, t reflect.Type, issues *[]service.ValidationIssue) {
+	fail := func(format string, args ...any) {
+		*issues = append(*issues, service.ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if t == timeType {
+		s, ok := v.(string)
+		if !ok {
+			fail("ожидалась дата (строка RFC 3339), получено %s", jsonKind(v))
+		} else if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			fail("некорректная дата %q", s)
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			fail("ожидалось boolean, получено %s", jsonKind(v))
+		}
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			fail("ожидалась строка, получено %s", jsonKind(v))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := v.(json.Number)
+		if !ok {
+			fail("ожидалось целое число, получено %s", jsonKind(v))
+		} else if _, err := n.Int64(); err != nil {
+			fail("ожидалось целое число, получено %s", n)
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := v.(json.Number); !ok {
+			fail("ожидалось число, получено %s", jsonKind(v))
+		}
+	case reflect.Slice:
+		if v == nil {
+			return
+		}
+		arr, ok := v.([]any)
+		if !ok {
+			fail("ожидался массив, получено %s", jsonKind(v))
+			return
+		}
+		for i, el := range arr {
+			checkJSONValue(fmt.Sprintf("%s[%d]", path, i), el, t.Elem(), issues)
+		}
+	case reflect.Map:
+		if v == nil {
+			return
+		}
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("ожидался объект, получено %s", jsonKind(v))
+			return
+		}
+		for _, k := range sortedKeys(obj) {
+			checkJSONValue(path+"."+k, obj[k], t.Elem(), issues)
+		}
+	case reflect.Pointer:
+		if v != nil {
+			checkJSONValue(path, v, t.Elem(), issues)
+		}
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("ожидался объект %s, получено %s", t.Name(), jsonKind(v))
+			return
+		}
+		known := make(map[string]bool)
+		for _, f := range jsonFields(t) {
+			known[f.Name] = true
+			fv, present := obj[f.Name]
+			if !present {
+				if !f.OmitEmpty {
+					*issues = append(*issues, service.ValidationIssue{
+						Path:    path + "." + f.Name,
+						Message: "отсутствует обязательное поле",
+					})
+				}
+				continue
+			}
+			checkJSONValue(path+"."+f.Name, fv, t.Field(f.Index).Type, issues)
+		}
+		for _, k := range sortedKeys(obj) {
+			if !known[k] {
+				*issues = append(*issues, service.ValidationIssue{
+					Path:    path + "." + k,
+					Message: "неизвестное поле " + t.Name(),
+				})
+			}
+		}
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonKind — название JSON-типа значения для сообщений об ошибках
+func jsonKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "строка"
+	case json.Number:
+		return "число"
+	case []any:
+		return "массив"
+	case map[string]any:
+		return "объект"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// isJSONSyntaxError — ошибка в самом JSON (а не чтения файла): о ней сообщается как о нарушении
+func isJSONSyntaxError(err error) bool {
+	var syn *json.SyntaxError
+	return errors.As(err, &syn) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// describeJSONError переводит ошибку разбора в сообщение со строкой и столбцом;
+// строка считается повторным чтением начала файла до места ошибки
+func describeJSONError(r io.ReadSeeker, offset int64, err error) string {
+	var syn *json.SyntaxError
+	isSyntax := errors.As(err, &syn)
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF) || isSyntax && syn.Error() == "unexpected end of JSON input":
+		offset, _ = r.Seek(0, io.SeekEnd)
+		err = errors.New("неожиданный конец файла")
+	case isSyntax:
+		offset = syn.Offset
+	case errors.Is(err, io.EOF):
+		return "ошибка разбора JSON: пустой файл"
+	}
+	line, col, ok := lineCol(r, offset)
+	if !ok {
+		return "ошибка разбора JSON: " + err.Error()
+	}
+	return fmt.Sprintf("ошибка разбора JSON (строка %d, столбец %d): %s", line, col, strings.TrimPrefix(err.Error(), "json: "))
+}
+
+// lineCol — строка и столбец байта offset
+func lineCol(r io.ReadSeeker, offset int64) (line, col int, ok bool) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, false
+	}
+	br := bufio.NewReader(io.LimitReader(r, offset))
+	line, col = 1, 1
+	var n int64
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			break
+		}
+		n++
+		if b == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+	}
+	return line, col, n == offset
+}
@@ -0,0 +1,78 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"fsjson/internal/domain/model"
+	"fsjson/internal/domain/service"
+)
+
+func issuePaths(issues []service.ValidationIssue) string {
+	var paths []string
+	for _, is := range issues {
+		paths = append(paths, is.Path)
+	}
+	return strings.Join(paths, " ")
+}
+
+func TestValidateJSON_Tree(t *testing.T) {
+	tree := testTree()
+	data, _ := json.Marshal(tree)
+	if shape, issues, err := ValidateJSON(data); err != nil || shape != ShapeTree || len(issues) != 0 {
+		t.Fatalf("корректное дерево: %s %v %v", shape, issues, err)
+	}
+
+	// нарушения инвариантов — только при корректной структуре
+	tree.Children[0].SizeBytes = 11
+	data, _ = json.Marshal(tree)
+	_, issues, _ := ValidateJSON(data)
+	if got := issuePaths(issues); got != "$.Children[0].SizeBytes $.SizeBytes" {
+		t.Errorf("инварианты: %s", got)
+	}
+
+	// поле после Children, неизвестное поле, неверный тип и пропущенное обязательное поле
+	raw := strings.Replace(string(data), `"ChildCount":1,`, `"Foo":[1,{"a":2}],"SizeBytes":"x",`, 1)
+	raw = strings.Replace(raw, `"FullName":"b.txt",`, "", 1)
+	raw = strings.TrimSuffix(raw, "}") + `,"Perm":"rwx"}`
+	_, issues, _ = ValidateJSON([]byte(raw))
+	want := "$.Children[0].Foo $.Children[0].SizeBytes $.Children[0].ChildCount $.Children[1].FullName $.Perm"
+	if got := issuePaths(issues); got != want {
+		t.Errorf("структура: %s, ожидалось %s", got, want)
+	}
+}
+
+func TestValidateJSON_Envelope(t *testing.T) {
+	tree := testTree()
+	snap := model.Snapshot{Schema: model.SnapshotSchema, Tree: &tree, Counts: service.CountTree(&tree)}
+	data, _ := json.Marshal(snap)
+	if shape, issues, _ := ValidateJSON(data); shape != ShapeTree || len(issues) != 0 {
+		t.Fatalf("корректный конверт: %s %v", shape, issues)
+	}
+
+	snap.Counts.Files++
+	data, _ = json.Marshal(snap)
+	if _, issues, _ := ValidateJSON(data); issuePaths(issues) != "$.Counts.Files" {
+		t.Errorf("счётчики: %v", issues)
+	}
+
+	snap.Tree, snap.Items = nil, service.FlattenTree(tree)
+	snap.Items = append(snap.Items, snap.Items[1])
+	data, _ = json.Marshal(snap)
+	shape, issues, _ := ValidateJSON(data)
+	if shape != ShapeFlat || issuePaths(issues) != "$.Items[4].FullPath" {
+		t.Errorf("flat в конверте: %s %v", shape, issues)
+	}
+}
+
+func TestValidateJSON_SyntaxError(t *testing.T) {
+	_, issues, err := ValidateJSON([]byte("{\n  \"IsDir\": trux\n}"))
+	if err != nil || len(issues) != 1 || !strings.Contains(issues[0].Message, "строка 2,") {
+		t.Fatalf("ожидалась ошибка разбора с позицией, получено %v %v", issues, err)
+	}
+	_, issues, _ = ValidateJSON([]byte(`{"IsDir": true, "Children": [`))
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "неожиданный конец файла") {
+		t.Fatalf("обрезанный файл: %v", issues)
+	}
+}
@@ -6,10 +6,6 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"net/url"
-	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"fsjson/internal/config"
@@ -22,14 +18,10 @@ import (
 var StaticFS embed.FS
 
 func StartWebServer(jsonPath string) {
-	data, err := os.ReadFile(jsonPath)
+	root, err := ReadTreeJSON(jsonPath)
 	if err != nil {
 		log.Fatalf("Ошибка чтения %s: %v", jsonPath, err)
 	}
-	var root model.FileInfo
-	if err := json.Unmarshal(data, &root); err != nil {
-		log.Fatalf("Ошибка разбора JSON: %v", err)
-	}
 	fmt.Printf("🌐 Веб-интерфейс запущен: http://localhost:8080\n📄 Загружен файл: %s\n", jsonPath)
 
 	http.HandleFunc("/api/tree", func(w http.ResponseWriter, r *http.Request) {
@@ -53,23 +45,52 @@ func StartWebServer(jsonPath string) {
 
 	http.Handle("/static/", http.StripPrefix("/static/",
 		http.FileServer(http.FS(StaticFS))))
+	contentIndex := ContentIndexLoader(jsonPath)
 	http.HandleFunc("/api/search", func(w http.ResponseWriter, r *http.Request) {
-		q := r.URL.Query()
-		params := service.SearchParams{
-			Query:     q.Get("query"),
-			Path:      q.Get("path"),
-			Types:     strings.Split(q.Get("types"), ","),
-			Recursive: q.Get("recursive") != "false",
-			Limit:     parseInt(q.Get("limit"), 100),
-			Offset:    parseInt(q.Get("offset"), 0),
-			SizeCmp:   parseSizeFilters(q),
-			Created:   parseTimeFiltersFromQuery(q, "created"),
-			Modified:  parseTimeFiltersFromQuery(q, "modified"),
+		params, err := config.ParseSearchParams(config.QueryValue(r.URL.Query()), time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		params.ContentIndex = contentIndex
+		results, err := service.SearchIn(service.TreeSource(&root), params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-		results := service.SearchFiles(&root, params)
 		writeJSON(w, results)
 	})
 
+	http.HandleFunc("/api/stats", func(w http.ResponseWriter, r *http.Request) {
+		params, err := config.ParseStatsParams(config.QueryValue(r.URL.Query()), time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		params.Search.ContentIndex = contentIndex
+		res, err := service.Aggregate(service.TreeSource(&root), params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, res)
+	})
+
+	http.HandleFunc("/api/top", func(w http.ResponseWriter, r *http.Request) {
+		params, err := config.ParseTopParams(config.QueryValue(r.URL.Query()), time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		params.Search.ContentIndex = contentIndex
+		res, err := service.Top(service.TreeSource(&root), params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, res)
+	})
+
 	http.HandleFunc("/api/duplicates", httpHandler.HandleDuplicates(&root))
 
 	log.Fatal(http.ListenAndServe(":8080", nil))
@@ -91,41 +112,6 @@ func findNodeByPath(node *model.FileInfo, path string) *model.FileInfo {
 	}
 	return nil
 }
-func parseSizeFilters(q url.Values) map[string]int64 {
-	m := make(map[string]int64)
-	for _, k := range []string{"gt", "gte", "lt", "lte", "eq"} {
-		if v := q.Get("size." + k); v != "" {
-			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
-				m[k] = n
-			}
-		}
-	}
-	return m
-}
-
-func parseInt(s string, def int) int {
-	if s == "" {
-		return def
-	}
-	n, err := strconv.Atoi(s)
-	if err != nil {
-		return def
-	}
-	return n
-}
-
-func parseTimeFiltersFromQuery(q url.Values, prefix string) map[string]time.Time {
-	m := make(map[string]time.Time)
-	for _, op := range []string{"gt", "gte", "lt", "lte"} {
-		key := prefix + "." + op
-		if v := q.Get(key); v != "" {
-			if t, err := config.ParseISOTime(v); err == nil {
-				m[op] = t
-			}
-		}
-	}
-	return m
-}
 
 var indexHTML = `
 <!DOCTYPE html>
@@ -3,16 +3,60 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"fsjson/internal/domain/model"
 	"fsjson/internal/domain/service"
 )
 
-// HandleDuplicates — возвращает список групп дубликатов
+// HandleDuplicates — возвращает список групп дубликатов.
+// Параметры: min_size, types, ext, path, sort (wasted|count|size), limit, offset, top_dirs.
 func HandleDuplicates(root *model.FileInfo) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		resp := service.FindDuplicates(root)
+		q := r.URL.Query()
+		sort, err := service.ParseDuplicateSort(q.Get("sort"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter := service.DuplicateFilter{
+			Types:      splitList(q.Get("types")),
+			Exts:       splitList(q.Get("ext")),
+			PathPrefix: q.Get("path"),
+			Sort:       sort,
+		}
+		for name, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset, "top_dirs": &filter.TopDirs} {
+			if v := q.Get(name); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 0 {
+					http.Error(w, "некорректный параметр "+name, http.StatusBadRequest)
+					return
+				}
+				*dst = n
+			}
+		}
+		if v := q.Get("min_size"); v != "" {
+			n, err := service.ParseSize(v)
+			if err != nil {
+				http.Error(w, "некорректный параметр min_size: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			filter.MinSize = n
+		}
+
+		resp, _ := service.FindDuplicatesIn(service.TreeSource(root), filter)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(resp)
 	}
 }
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}